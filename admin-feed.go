@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const adminFeedBuffer = 64 // Events queued per dashboard before dropping
+
+// AdminEvent is one entry of the admin feed
+type AdminEvent struct {
+	Event string      `json:"event"`
+	Time  int64       `json:"time"` // Unix milliseconds
+	Data  interface{} `json:"data,omitempty"`
+}
+
+// AdminFeed fans server events out to connected ops dashboards. A slow
+// dashboard misses events rather than holding up the game
+type AdminFeed struct {
+	mu          sync.Mutex
+	subscribers map[chan []byte]bool
+}
+
+var adminFeed = &AdminFeed{
+	subscribers: make(map[chan []byte]bool),
+}
+
+func (f *AdminFeed) Publish(event string, data interface{}) {
+	jsonData, err := json.Marshal(AdminEvent{
+		Event: event,
+		Time:  time.Now().UnixMilli(),
+		Data:  data,
+	})
+	if err != nil {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for subscriber := range f.subscribers {
+		select {
+		case subscriber <- jsonData:
+		default:
+		}
+	}
+}
+
+func (f *AdminFeed) subscribe() chan []byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	subscriber := make(chan []byte, adminFeedBuffer)
+	f.subscribers[subscriber] = true
+	return subscriber
+}
+
+func (f *AdminFeed) unsubscribe(subscriber chan []byte) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.subscribers, subscriber)
+}
+
+// logError logs a server error, tagged with the request or connection it
+// happened on, and passes it on to the admin feed
+func logError(requestID string, message string, err error) {
+	log.Printf("❌ [%s] %s: %v\n", requestID, message, err)
+	adminFeed.Publish("error", map[string]interface{}{
+		"requestId": requestID,
+		"message":   message,
+		"error":     err.Error(),
+	})
+	captureError("error", err, map[string]interface{}{
+		"requestId": requestID,
+		"message":   message,
+	})
+}
+
+// roomListing summarizes the room for the lobby and the admin feed
+func roomListing(room *Room) RoomListing {
+	// mutex is already locked by caller function
+	return RoomListing{
+		Code:       room.Code,
+		Language:   room.Settings.Language,
+		Region:     room.Region,
+		Players:    countPlayers(room),
+		Spectators: countSpectators(room),
+		IsActive:   room.GameState.IsActive,
+	}
+}
+
+// publishPlayerCount tells the admin feed how many are in the room, and
+// whether that just opened or closed it. A room going from two players to
+// one isn't opening, so the count is compared with the last one published
+func publishPlayerCount(room *Room) {
+	// mutex is already locked by caller function
+	listing := roomListing(room)
+	adminFeed.Publish("playerCount", listing)
+
+	previous := room.PublishedPlayers
+	room.PublishedPlayers = len(room.Clients)
+	switch {
+	case previous > 0 && room.PublishedPlayers == 0:
+		adminFeed.Publish("roomClosed", nil)
+	case previous == 0 && room.PublishedPlayers > 0:
+		adminFeed.Publish("roomOpened", listing)
+	}
+}
+
+// adminFeedHandler streams admin events over a WebSocket, starting with a
+// snapshot of the room
+func adminFeedHandler(c *gin.Context) {
+	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	subscriber := adminFeed.subscribe()
+	defer adminFeed.unsubscribe(subscriber)
+
+	log.Println("📊 Admin dashboard connected")
+
+	room.mu.RLock()
+	snapshot, _ := json.Marshal(AdminEvent{
+		Event: "snapshot",
+		Time:  time.Now().UnixMilli(),
+		Data: map[string]interface{}{
+			"rooms":   []RoomListing{roomListing(room)},
+			"reports": room.Reports,
+		},
+	})
+	room.mu.RUnlock()
+
+	if conn.WriteMessage(websocket.TextMessage, snapshot) != nil {
+		return
+	}
+
+	// Dashboards only listen, reading just notices when they go away
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-closed:
+			log.Println("📊 Admin dashboard disconnected")
+			return
+		case jsonData := <-subscriber:
+			if conn.WriteMessage(websocket.TextMessage, jsonData) != nil {
+				return
+			}
+		}
+	}
+}
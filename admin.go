@@ -0,0 +1,182 @@
+package main
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WordsRequest struct {
+	Language string   `json:"language"`
+	Category string   `json:"category"`
+	Words    []string `json:"words"`
+}
+
+// adminAuth only lets requests through that carry the ADMIN_TOKEN as a
+// bearer token. Without ADMIN_TOKEN set the admin API is switched off
+func adminAuth() gin.HandlerFunc {
+	return tokenAuth(os.Getenv("ADMIN_TOKEN"))
+}
+
+// adminFeedAuth is adminAuth for the admin feed. Browsers can't set headers
+// on a WebSocket, so only the feed may pass the token as a query parameter
+func adminFeedAuth() gin.HandlerFunc {
+	token := os.Getenv("ADMIN_TOKEN")
+	return func(c *gin.Context) {
+		given := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if given == "" {
+			given = c.Query("token")
+		}
+		checkToken(c, token, given)
+	}
+}
+
+// tokenAuth only lets requests through that carry token as a bearer token,
+// an empty token lets nothing through
+func tokenAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		checkToken(c, token, strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "))
+	}
+}
+
+func checkToken(c *gin.Context, token string, given string) {
+	if token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"error": "unauthorized",
+		})
+		return
+	}
+	c.Next()
+}
+
+func setupAdminRoutes(router *gin.Engine) {
+	admin := router.Group("/admin", adminAuth())
+
+	admin.GET("/words", listWordsHandler)
+	admin.POST("/words", addWordsHandler)
+	admin.POST("/words/import", importWordsHandler)
+	admin.DELETE("/words", removeWordsHandler)
+	admin.GET("/words/stats", wordStatsHandler)
+
+	admin.GET("/suggestions", listSuggestionsHandler)
+	admin.POST("/suggestions/:id/approve", approveSuggestionHandler)
+	admin.DELETE("/suggestions/:id", rejectSuggestionHandler)
+
+	admin.GET("/chat", chatLogHandler)
+
+	admin.GET("/players/:id/data", exportPlayerDataHandler)
+	admin.DELETE("/players/:id/data", deletePlayerDataHandler)
+
+	admin.GET("/diagnostics", diagnosticsHandler)
+
+	router.GET("/admin/feed", adminFeedAuth(), adminFeedHandler)
+}
+
+// listWordsHandler returns the categories of one language, or of all of
+// them when no "language" query parameter is given, along with the
+// difficulty words were imported with
+func listWordsHandler(c *gin.Context) {
+	languages := wordStore.Languages()
+	if language := c.Query("language"); language != "" {
+		languages = []string{language}
+	}
+
+	packs := make(map[string]map[string][]string)
+	difficulty := make(map[string]map[string]string)
+	for _, language := range languages {
+		packs[language] = wordStore.Categories(language)
+		difficulty[language] = wordStore.Difficulties(language)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"packs":      packs,
+		"difficulty": difficulty,
+	})
+}
+
+func addWordsHandler(c *gin.Context) {
+	request, ok := bindWordsRequest(c)
+	if !ok {
+		return
+	}
+
+	if len(request.Words) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "no words given",
+		})
+		return
+	}
+
+	if err := wordStore.Add(request.Language, request.Category, request.Words); err != nil {
+		logError(c.GetString("requestId"), "Failed to save words", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to save words",
+		})
+		return
+	}
+
+	log.Printf("📝 Added %d words to %s/%s\n", len(request.Words), request.Language, request.Category)
+	c.JSON(http.StatusOK, gin.H{
+		"words": wordStore.Categories(request.Language)[request.Category],
+	})
+}
+
+// removeWordsHandler removes the given words from a category, or the whole
+// category when no words are given
+func removeWordsHandler(c *gin.Context) {
+	request, ok := bindWordsRequest(c)
+	if !ok {
+		return
+	}
+
+	err := wordStore.Remove(request.Language, request.Category, request.Words)
+	switch err {
+	case nil:
+	case errUnknownCategory:
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	case errPackTooSmall:
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+		})
+		return
+	default:
+		logError(c.GetString("requestId"), "Failed to save words", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to save words",
+		})
+		return
+	}
+
+	log.Printf("🗑️ Removed words from %s/%s\n", request.Language, request.Category)
+	c.JSON(http.StatusOK, gin.H{
+		"words": wordStore.Categories(request.Language)[request.Category],
+	})
+}
+
+// bindWordsRequest reads a words request body, defaulting the language and
+// category, and answers with an error itself if the body is unusable
+func bindWordsRequest(c *gin.Context) (WordsRequest, bool) {
+	var request WordsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request body",
+		})
+		return request, false
+	}
+
+	if request.Language == "" {
+		request.Language = defaultLanguage
+	}
+	if request.Category == "" {
+		request.Category = defaultCategory
+	}
+
+	return request, true
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	analyticsQueueSize = 1000 // Events waiting for the sink before dropping
+	analyticsTimeout   = 5 * time.Second
+)
+
+// AnalyticsEvent is one gameplay event for offline analysis
+type AnalyticsEvent struct {
+	Event    string                 `json:"event"`
+	Time     time.Time              `json:"time"`
+	Language string                 `json:"language"`
+	Variant  string                 `json:"variant,omitempty"`
+	Data     map[string]interface{} `json:"data,omitempty"`
+}
+
+// analyticsEvents queues events for the sink set in ANALYTICS_URL or
+// ANALYTICS_FILE, it is nil when analytics are off
+var analyticsEvents = startAnalytics(os.Getenv("ANALYTICS_URL"), os.Getenv("ANALYTICS_FILE"))
+
+// startAnalytics starts writing events to a sink: POSTed as JSON to url,
+// which can front a queue such as Kafka through its HTTP bridge, or else
+// appended as JSON lines to the file at path
+func startAnalytics(url string, path string) chan AnalyticsEvent {
+	var write func([]byte) error
+	switch {
+	case url != "":
+		client := &http.Client{Timeout: analyticsTimeout}
+		write = func(body []byte) error {
+			resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+			if err != nil {
+				return err
+			}
+			resp.Body.Close()
+			if resp.StatusCode >= 300 {
+				return fmt.Errorf("sink answered %s", resp.Status)
+			}
+			return nil
+		}
+	case path != "":
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			log.Printf("⚠️ Analytics disabled, failed to open %s: %v\n", path, err)
+			return nil
+		}
+		write = func(body []byte) error {
+			_, err := file.Write(append(body, '\n'))
+			return err
+		}
+	default:
+		return nil
+	}
+
+	events := make(chan AnalyticsEvent, analyticsQueueSize)
+	go func() {
+		for event := range events {
+			body, err := json.Marshal(event)
+			if err != nil {
+				continue
+			}
+			if err := write(body); err != nil {
+				log.Printf("⚠️ Failed to send analytics event: %v\n", err)
+			}
+		}
+	}()
+
+	return events
+}
+
+// trackEvent hands a gameplay event to the analytics sink, if there is one.
+// It never blocks, events are dropped when the sink falls behind
+func trackEvent(room *Room, event string, data map[string]interface{}) {
+	// mutex is already locked by caller function
+	if analyticsEvents == nil {
+		return
+	}
+
+	select {
+	case analyticsEvents <- AnalyticsEvent{
+		Event:    event,
+		Time:     time.Now(),
+		Language: room.Settings.Language,
+		Variant:  room.Variant,
+		Data:     data,
+	}:
+	default:
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+const (
+	defaultAutoHintAt = 0 // Off, a stuck room only gets the extra hint if the owner sets one
+	minAutoHintAt     = 25
+	maxAutoHintAt     = 95
+)
+
+// checkAutoHint helps out once the configured share of the round has gone
+// by without anyone guessing: one more letter is uncovered, and the word's
+// category is posted as a clue if it has one and wasn't announced already
+func checkAutoHint(room *Room, elapsed int) {
+	// mutex is already locked by caller function
+	state := room.GameState
+	if room.Settings.AutoHintAt == 0 || state.AutoHinted || len(state.PlayersGuessed) > 0 ||
+		elapsed*100 < state.RoundTime*room.Settings.AutoHintAt {
+		return
+	}
+	state.AutoHinted = true
+
+	letter := state.HintPolicy != HintNone && countHidden(state.WordHint) > 1
+	if letter {
+		state.WordHint = revealLetter(room, state.WordHint, state.CurrentWord)
+	}
+
+	category := ""
+	if state.Category == "" {
+		category = wordCategory(room, state.CurrentWord)
+	}
+
+	if !letter && category == "" {
+		return
+	}
+
+	broadcastChatMessage(room, systemMessage(room, "autoHint"))
+	if category != "" {
+		broadcastChatMessage(room, systemMessage(room, "categoryClue", category))
+	}
+	broadcastEvent(room, "autoHint", map[string]interface{}{
+		"letter":   letter,
+		"category": category,
+	})
+}
+
+// wordCategory is the category a word is filed under, empty for words in
+// the default one, which tells guessers nothing
+func wordCategory(room *Room, word string) string {
+	// mutex is already locked by caller function
+	category := wordStore.CategoryOf(room.Settings.Language, word)
+	if category == defaultCategory {
+		return ""
+	}
+	return category
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+const (
+	autoStartCountdown  = 10 * time.Second
+	maxAutoStartPlayers = 50
+)
+
+// checkAutoStart starts the countdown once the room reaches the owner's
+// target player count
+func checkAutoStart(room *Room) {
+	// mutex is already locked by caller function
+	target := room.Settings.AutoStartPlayers
+	if target == 0 || room.GameState.IsActive || room.AutoStartTimer != nil || countPlayers(room) < target {
+		return
+	}
+
+	log.Printf("⏱️ Room reached %d players, auto-starting\n", target)
+
+	var timer *time.Timer
+	timer = time.AfterFunc(autoStartCountdown, func() {
+		defer recoverRoom(room, "auto-start timer")
+
+		room.mu.Lock()
+		defer room.mu.Unlock()
+
+		// Cancelled, or replaced by a newer countdown
+		if room.AutoStartTimer != timer {
+			return
+		}
+		room.AutoStartTimer = nil
+
+		if !room.GameState.IsActive && countPlayers(room) >= 2 {
+			startNewRound(room)
+		}
+	})
+	room.AutoStartTimer = timer
+
+	broadcastMessage(room, Message{
+		Type: "autoStart",
+		Data: map[string]interface{}{
+			"startsAt": time.Now().Add(autoStartCountdown).UnixMilli(),
+		},
+	})
+
+	broadcastChatMessage(room, systemMessage(room, "autoStartCountdown", int(autoStartCountdown.Seconds())))
+}
+
+// cancelAutoStart stops a running auto-start countdown
+func cancelAutoStart(room *Room) {
+	// mutex is already locked by caller function
+	if room.AutoStartTimer == nil {
+		return
+	}
+
+	room.AutoStartTimer.Stop()
+	room.AutoStartTimer = nil
+
+	broadcastMessage(room, Message{
+		Type: "autoStartCancelled",
+		Data: nil,
+	})
+
+	broadcastChatMessage(room, systemMessage(room, "autoStartCancelled"))
+}
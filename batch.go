@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// messageBatch collects the frames written during one state transition so
+// each client receives them together as a single tick frame
+type messageBatch struct {
+	mu     sync.Mutex
+	frames map[*Client][][]byte
+}
+
+// beginBatch starts collecting outgoing messages for the room. It returns
+// false if a batch is already open, in which case the caller must not flush
+func beginBatch(room *Room) bool {
+	// mutex is already locked by caller function
+	return room.batch.CompareAndSwap(nil, &messageBatch{
+		frames: make(map[*Client][][]byte),
+	})
+}
+
+// flushBatch sends every client its collected messages, wrapped in a tick
+// message when there is more than one
+func flushBatch(room *Room) {
+	// mutex is already locked by caller function
+	batch := room.batch.Swap(nil)
+	if batch == nil {
+		return
+	}
+
+	batch.mu.Lock()
+	defer batch.mu.Unlock()
+
+	clients := make([]*Client, 0, len(batch.frames))
+	for client := range batch.frames {
+		clients = append(clients, client)
+	}
+
+	fanOut(clients, func(client *Client) {
+		frames := batch.frames[client]
+		if len(frames) == 1 {
+			writeFrame(client, frames[0])
+			return
+		}
+
+		var tick bytes.Buffer
+		tick.WriteString(`{"type":"tick","data":[`)
+		tick.Write(bytes.Join(frames, []byte(",")))
+		tick.WriteString(`]}`)
+
+		writeFrame(client, tick.Bytes())
+	})
+}
+
+// writeToClient sends an already marshaled message to the client, or queues
+// it if a batch is open
+func writeToClient(client *Client, jsonData []byte) error {
+	if batch := room.batch.Load(); batch != nil {
+		batch.mu.Lock()
+		batch.frames[client] = append(batch.frames[client], jsonData)
+		batch.mu.Unlock()
+		return nil
+	}
+
+	return writeFrame(client, jsonData)
+}
+
+// writeFrame writes straight to the client's socket, keeping count of
+// failed writes
+func writeFrame(client *Client, jsonData []byte) error {
+	err := client.Conn.WriteMessage(websocket.TextMessage, jsonData)
+	trackWriteError(client, err)
+	return err
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultBlobRetention = 168 // Hours media is kept, a week
+	blobPurgeInterval    = time.Hour
+	defaultBlobDir       = "media"
+
+	// Only kept in memory, a replay is one blob and a drawing three
+	defaultReplays  = 20 // Finished games kept for download
+	defaultDrawings = 50 // Finished drawings kept for sharing
+)
+
+const (
+	jsonContentType = "application/json; charset=utf-8"
+	svgContentType  = "image/svg+xml"
+	pngContentType  = "image/png"
+)
+
+var errBlobNotFound = errors.New("blob not found")
+
+// BlobInfo describes a stored blob
+type BlobInfo struct {
+	Key      string
+	Modified time.Time
+}
+
+// BlobStore keeps media such as replays and drawings, under keys made of
+// slash separated names. Stores are called without the room locked and
+// may be slow
+type BlobStore interface {
+	Put(key string, data []byte, contentType string) error
+	Get(key string) ([]byte, error) // errBlobNotFound if there is none
+	Delete(key string) error
+	List(prefix string) ([]BlobInfo, error) // Ordered by key
+}
+
+// blobs is picked with STORAGE: "memory" (the default) keeps media until a
+// restart, "disk" writes it under STORAGE_DIR, and "s3" puts it in the
+// S3_BUCKET of an S3-compatible service. Media older than
+// STORAGE_RETENTION_HOURS is deleted
+var blobs = openBlobStore(os.Getenv("STORAGE"))
+
+func openBlobStore(kind string) BlobStore {
+	var store BlobStore
+	switch kind {
+	case "disk":
+		dir := os.Getenv("STORAGE_DIR")
+		if dir == "" {
+			dir = defaultBlobDir
+		}
+		store = &diskStore{dir: dir}
+	case "s3":
+		store = newS3Store()
+	default:
+		store = &memoryStore{
+			blobs: make(map[string]memoryBlob),
+			limits: map[string]int{
+				"replays/":  envInt("REPLAY_LIMIT", defaultReplays),
+				"drawings/": envInt("DRAWING_LIMIT", defaultDrawings) * 3,
+			},
+		}
+	}
+
+	retention := time.Duration(envInt("STORAGE_RETENTION_HOURS", defaultBlobRetention)) * time.Hour
+	go purgeBlobs(store, retention)
+	return store
+}
+
+// purgeBlobs deletes media past its retention every hour
+func purgeBlobs(store BlobStore, retention time.Duration) {
+	for {
+		infos, err := store.List("")
+		if err != nil {
+			logError("storage", "Failed to list media for purging", err)
+		}
+
+		cutoff := time.Now().Add(-retention)
+		for _, info := range infos {
+			if info.Modified.Before(cutoff) {
+				if err := store.Delete(info.Key); err != nil {
+					logError("storage", "Failed to purge "+info.Key, err)
+				}
+			}
+		}
+
+		time.Sleep(blobPurgeInterval)
+	}
+}
+
+// putBlob stores media in the background, the game doesn't wait on storage
+func putBlob(key string, data []byte, contentType string) {
+	go func() {
+		if err := blobs.Put(key, data, contentType); err != nil {
+			logError("storage", "Failed to store "+key, err)
+		}
+	}()
+}
+
+// isBlobName checks a key part taken from a URL is a single plain name
+func isBlobName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, `/\`)
+}
+
+// respondUnknownMedia answers 404 for media that never existed or has
+// expired
+func respondUnknownMedia(c *gin.Context, kind string) {
+	c.JSON(http.StatusNotFound, gin.H{
+		"error": "unknown " + kind,
+	})
+}
+
+type memoryBlob struct {
+	data     []byte
+	modified time.Time
+}
+
+// memoryStore keeps blobs in memory, they are gone after a restart. The
+// oldest blobs under a prefix are dropped past its limit, since retention
+// alone would let memory grow for a week
+type memoryStore struct {
+	mu     sync.RWMutex
+	blobs  map[string]memoryBlob
+	limits map[string]int // Most blobs kept per key prefix
+}
+
+func (s *memoryStore) Put(key string, data []byte, contentType string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.blobs[key] = memoryBlob{data: data, modified: time.Now()}
+	for prefix, limit := range s.limits {
+		if strings.HasPrefix(key, prefix) {
+			s.evict(prefix, limit)
+		}
+	}
+	return nil
+}
+
+// evict drops the oldest blobs under prefix until at most limit are left
+func (s *memoryStore) evict(prefix string, limit int) {
+	// mutex is already locked by caller function
+	keys := []string{}
+	for key := range s.blobs {
+		if strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) <= limit {
+		return
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return s.blobs[keys[i]].modified.Before(s.blobs[keys[j]].modified)
+	})
+	for _, key := range keys[:len(keys)-limit] {
+		delete(s.blobs, key)
+	}
+}
+
+func (s *memoryStore) Get(key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	blob, ok := s.blobs[key]
+	if !ok {
+		return nil, errBlobNotFound
+	}
+	return blob.data, nil
+}
+
+func (s *memoryStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.blobs, key)
+	return nil
+}
+
+func (s *memoryStore) List(prefix string) ([]BlobInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	infos := []BlobInfo{}
+	for key, blob := range s.blobs {
+		if strings.HasPrefix(key, prefix) {
+			infos = append(infos, BlobInfo{Key: key, Modified: blob.modified})
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Key < infos[j].Key
+	})
+	return infos, nil
+}
+
+// diskStore keeps blobs as files in a directory
+type diskStore struct {
+	dir string
+}
+
+func (s *diskStore) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(key))
+}
+
+func (s *diskStore) Put(key string, data []byte, contentType string) error {
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	// Written to the side first, so a reader never sees half a file
+	temp := path + ".tmp"
+	if err := os.WriteFile(temp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(temp, path)
+}
+
+func (s *diskStore) Get(key string) ([]byte, error) {
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, errBlobNotFound
+	}
+	return data, err
+}
+
+func (s *diskStore) Delete(key string) error {
+	err := os.Remove(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *diskStore) List(prefix string) ([]BlobInfo, error) {
+	infos := []BlobInfo{}
+	err := filepath.WalkDir(s.dir, func(path string, entry fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil || entry.IsDir() || strings.HasSuffix(path, ".tmp") {
+			return err
+		}
+
+		relative, err := filepath.Rel(s.dir, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(relative)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		infos = append(infos, BlobInfo{Key: key, Modified: info.ModTime()})
+		return nil
+	})
+	return infos, err
+}
@@ -0,0 +1,83 @@
+package main
+
+import "sync"
+
+const (
+	broadcastWorkers     = 16 // Most writes in flight for one broadcast
+	parallelBroadcastMin = 8  // Below this many clients a plain loop is quicker
+)
+
+// fanOut calls send for every client, spread over a bounded set of workers
+// for big rooms so one slow socket doesn't hold up everyone after it. It
+// returns once every client has been handled, so per-client ordering of
+// consecutive broadcasts is kept
+func fanOut(clients []*Client, send func(client *Client)) {
+	if len(clients) < parallelBroadcastMin {
+		for _, client := range clients {
+			send(client)
+		}
+		return
+	}
+
+	jobs := make(chan *Client)
+	var wg sync.WaitGroup
+
+	for i := 0; i < min(broadcastWorkers, len(clients)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for client := range jobs {
+				sendRecovered(client, send)
+			}
+		}()
+	}
+
+	for _, client := range clients {
+		jobs <- client
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// sendRecovered runs send for one client on a worker, a panic skips just
+// that client instead of crashing the server
+func sendRecovered(client *Client, send func(client *Client)) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			reportPanic("broadcast", recovered)
+		}
+	}()
+
+	send(client)
+}
+
+// roomClients lists the clients in the room, leaving out exceptID if set
+func roomClients(room *Room, exceptID string) []*Client {
+	// mutex is already locked by caller function
+	clients := make([]*Client, 0, len(room.Clients))
+	for _, client := range room.Clients {
+		if client.ID != exceptID {
+			clients = append(clients, client)
+		}
+	}
+	return clients
+}
+
+// writeToAll sends the same marshaled message to every client in the room
+func writeToAll(room *Room, jsonData []byte) {
+	writeToOthers(room, "", jsonData)
+}
+
+// writeToOthers sends the same marshaled message to everyone but senderID.
+// Only a broadcast to everyone moves the sequence number on
+func writeToOthers(room *Room, senderID string, jsonData []byte) {
+	seq := room.Seq.Load()
+	if senderID == "" {
+		seq = room.Seq.Add(1)
+	}
+	jsonData = withSeq(jsonData, seq)
+
+	fanOut(roomClients(room, senderID), func(client *Client) {
+		writeToClient(client, jsonData)
+	})
+}
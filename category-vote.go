@@ -0,0 +1,146 @@
+package main
+
+import (
+	"log"
+	"sort"
+	"strings"
+	"time"
+)
+
+const categoryVoteOptions = 3
+
+// CategoryVote lets the players pick the category of the next word during
+// the intermission. Players who don't vote leave it to the others
+type CategoryVote struct {
+	Options []string       `json:"options"`
+	EndsAt  int64          `json:"endsAt"` // Unix milliseconds, when the next turn starts
+	Votes   map[string]int `json:"-"`      // Player ID to option index
+}
+
+type CategoryVoteResult struct {
+	Options []string `json:"options"`
+	Counts  []int    `json:"counts"`
+	Winner  string   `json:"winner,omitempty"` // Empty when nobody voted
+}
+
+// openCategoryVote offers the players a few categories to pick the next
+// word from, if the room's language has enough of them. A room limited to
+// some categories only votes between those
+func openCategoryVote(room *Room, endsAt time.Time) {
+	// mutex is already locked by caller function
+	room.CategoryVote = nil
+	if !room.Settings.CategoryVote {
+		return
+	}
+
+	allowed := []string{}
+	if room.Settings.WordCategories != "" {
+		allowed = strings.Split(room.Settings.WordCategories, ",")
+	}
+
+	options := []string{}
+	for category, words := range wordStore.Categories(room.Settings.Language) {
+		if len(words) >= wordChoiceCount(room) && (len(allowed) == 0 || containsWord(allowed, category)) {
+			options = append(options, category)
+		}
+	}
+	if len(options) < 2 {
+		return
+	}
+
+	// Sorted first, so a room with a pinned seed shuffles them the same way every time
+	sort.Strings(options)
+	room.Rand.Shuffle(len(options), func(i, j int) {
+		options[i], options[j] = options[j], options[i]
+	})
+	room.CategoryVote = &CategoryVote{
+		Options: options[:min(len(options), categoryVoteOptions)],
+		EndsAt:  endsAt.UnixMilli(),
+		Votes:   make(map[string]int),
+	}
+
+	broadcastChatMessage(room, systemMessage(room, "categoryVoteOpen"))
+	broadcastMessage(room, Message{
+		Type: "categoryVote",
+		Data: room.CategoryVote,
+	})
+}
+
+func voteCategory(room *Room, client *Client, data map[string]interface{}) {
+	// mutex is already locked by caller function
+	if room.CategoryVote == nil || !canVoteCategory(room, client) {
+		return
+	}
+
+	option, ok := data["option"].(float64)
+	if !ok || int(option) < 0 || int(option) >= len(room.CategoryVote.Options) {
+		return
+	}
+	room.CategoryVote.Votes[client.ID] = int(option)
+}
+
+// canVoteCategory reports whether a player guesses in the turn the vote is
+// for. The next drawer doesn't, they get to pick from the words anyway
+func canVoteCategory(room *Room, client *Client) bool {
+	// mutex is already locked by caller function
+	if client.Type == "spectator" {
+		return false
+	}
+	if upNext := room.GameState.UpNext; len(upNext) > 0 && upNext[0] == client.ID {
+		return false
+	}
+	if room.GameState.Tiebreaker {
+		return containsID(room.TiebreakerPlayers, client.ID)
+	}
+	return true
+}
+
+// closeCategoryVote counts the votes as the next turn starts. The winning
+// category, ties broken at random, is what that turn's words come from
+func closeCategoryVote(room *Room) {
+	// mutex is already locked by caller function
+	vote := room.CategoryVote
+	room.CategoryVote = nil
+	room.NextCategory = ""
+
+	if vote == nil {
+		return
+	}
+
+	counts := make([]int, len(vote.Options))
+	total := 0
+	for id, option := range vote.Votes {
+		// Votes of players who left don't count
+		if _, ok := room.Clients[id]; ok {
+			counts[option]++
+			total++
+		}
+	}
+
+	result := CategoryVoteResult{
+		Options: vote.Options,
+		Counts:  counts,
+	}
+
+	best := []int{}
+	for i, count := range counts {
+		if count > 0 && (len(best) == 0 || count > counts[best[0]]) {
+			best = []int{i}
+		} else if count > 0 && count == counts[best[0]] {
+			best = append(best, i)
+		}
+	}
+	if len(best) > 0 {
+		winner := best[room.Rand.Intn(len(best))]
+		result.Winner = vote.Options[winner]
+		room.NextCategory = result.Winner
+
+		log.Printf("🗳️ Category vote closed: %s\n", result.Winner)
+		broadcastChatMessage(room, systemMessage(room, "categoryChosen", result.Winner, counts[winner], total))
+	}
+
+	broadcastMessage(room, Message{
+		Type: "categoryVoteResult",
+		Data: result,
+	})
+}
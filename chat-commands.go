@@ -0,0 +1,254 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Chat command keys mapped to the updateSettings field they change
+var settingsKeys = map[string]string{
+	"time":       "roundTime",
+	"hints":      "hintPolicy",
+	"bonus":      "bonusWord",
+	"autostart":  "autoStartPlayers",
+	"background": "background",
+	"language":   "language",
+	"minlength":  "minWordLength",
+	"maxlength":  "maxWordLength",
+	"contains":   "containsMatch",
+	"rounds":     "rounds",
+	"keepscores": "keepScores",
+	"idle":       "drawerIdleTime",
+	"hidetimer":  "hideDrawerTimer",
+	"break":      "intermission",
+	"manual":     "manualTurns",
+	"autohint":   "autoHintAt",
+	"shorten":    "shortenTo",
+	"textguard":  "textGuard",
+	"twitch":     "twitchGuessing",
+	"scoring":    "scoring",
+	"categories": "wordCategories",
+	"preset":     "preset",
+	"mode":       "mode",
+	"target":     "targetScore",
+	"teams":      "teams",
+	"balance":    "teamBalance",
+	"catvote":    "categoryVote",
+	"category":   "announceCategory",
+}
+
+// handleChatCommand runs a chat message starting with "/" as a command
+// instead of broadcasting it
+func handleChatCommand(room *Room, client *Client, text string) {
+	// mutex is already locked by caller function
+	command, args, _ := strings.Cut(strings.TrimSpace(text), " ")
+	command = strings.ToLower(command)
+	args = strings.TrimSpace(args)
+
+	switch command {
+	case "/kick", "/skip", "/settings", "/mute", "/unmute":
+		if client.Type != "owner" {
+			commandReply(room, client, "ownerOnlyCommand", command)
+			return
+		}
+	case "/votekick", "/voteskip", "/report":
+		if client.Type == "spectator" {
+			commandReply(room, client, "spectatorCommand", command)
+			return
+		}
+	default:
+		commandReply(room, client, "unknownCommand", command)
+		return
+	}
+
+	switch command {
+	case "/kick":
+		target := findClientByName(room, args)
+		if target == nil || target.ID == client.ID {
+			commandReply(room, client, "usage", "/kick <player name>")
+			return
+		}
+		kickClient(room, target)
+
+	case "/skip":
+		if !room.GameState.IsActive {
+			commandReply(room, client, "noRoundToSkip")
+			return
+		}
+
+		broadcastChatMessage(room, systemMessage(room, "ownerSkipped"))
+		broadcastEvent(room, "roundSkipped", map[string]interface{}{
+			"reason": "owner",
+		})
+
+		// endRound takes the lock itself, so it runs once we're done here
+		state := room.GameState
+		goRoom(room, "endRound", func() {
+			endRound(room, state)
+		})
+
+	case "/settings":
+		key, value, _ := strings.Cut(args, " ")
+		field, ok := settingsKeys[strings.ToLower(key)]
+		if !ok || value == "" {
+			commandReply(room, client, "usage", "/settings <time|hints|bonus|autostart|background|language|minlength|maxlength|contains|rounds|keepscores|idle|hidetimer|break|manual|autohint|shorten|textguard|twitch|scoring|categories|preset|mode|target|teams|balance|catvote|category> <value>")
+			return
+		}
+
+		if room.GameState.IsActive {
+			commandReply(room, client, "settingsDuringGame")
+			return
+		}
+
+		before := room.Settings
+		updateSettings(room, map[string]interface{}{
+			field: parseSettingValue(field, value),
+		})
+		if room.Settings == before {
+			commandReply(room, client, "invalidSetting", key, value)
+			return
+		}
+
+		broadcastSettings(room)
+		checkAutoStart(room)
+
+	case "/mute", "/unmute":
+		target := findClientByName(room, args)
+		if target == nil || target.ID == client.ID {
+			commandReply(room, client, "usage", command+" <player name>")
+			return
+		}
+
+		target.Muted = command == "/mute"
+		if target.Muted {
+			commandReply(room, client, "playerMuted", target.Username)
+		} else {
+			commandReply(room, client, "playerUnmuted", target.Username)
+		}
+
+	case "/votekick":
+		target := findClientByName(room, args)
+		if target == nil || target.ID == client.ID {
+			commandReply(room, client, "usage", "/votekick <player name>")
+			return
+		}
+		voteKick(room, client, target)
+
+	case "/voteskip":
+		if !room.GameState.IsActive {
+			commandReply(room, client, "noRoundToSkip")
+			return
+		}
+		voteSkip(room, client)
+
+	case "/report":
+		// Names can have spaces, so the reason comes after the longest
+		// leading match of a player's name
+		target, reason := findReportTarget(room, args)
+		if target == nil || target.ID == client.ID || reason == "" {
+			commandReply(room, client, "usage", "/report <player name> <reason>")
+			return
+		}
+		reportPlayer(room, client, target, reason)
+		commandReply(room, client, "reportSent", target.Username)
+	}
+}
+
+// findReportTarget splits "<player name> <reason>" into the player and the
+// reason
+func findReportTarget(room *Room, args string) (*Client, string) {
+	// mutex is already locked by caller function
+	var target *Client
+	for _, c := range room.Clients {
+		name := c.Username
+		if len(args) > len(name) && strings.EqualFold(args[:len(name)], name) && args[len(name)] == ' ' {
+			if target == nil || len(name) > len(target.Username) {
+				target = c
+			}
+		}
+	}
+
+	if target == nil {
+		return nil, ""
+	}
+	return target, strings.TrimSpace(args[len(target.Username):])
+}
+
+// stringSettings are the settings holding a string, by whether an empty
+// string turns them off
+var stringSettings = map[string]bool{
+	"hintPolicy":     false,
+	"background":     true,
+	"language":       false,
+	"textGuard":      true,
+	"scoring":        false,
+	"wordCategories": true,
+	"preset":         false,
+	"mode":           false,
+	"teamBalance":    false,
+}
+
+// parseSettingValue turns a typed command argument into the JSON-like type
+// updateSettings expects for the field. String settings get the argument
+// as typed, or "" for "off" and "none" when that turns them off
+func parseSettingValue(field string, value string) interface{} {
+	if emptyOff, ok := stringSettings[field]; ok {
+		if emptyOff && (strings.EqualFold(value, "off") || strings.EqualFold(value, "none")) {
+			return ""
+		}
+		return value
+	}
+
+	switch strings.ToLower(value) {
+	case "on", "true", "yes":
+		return true
+	case "off", "false", "no":
+		return false
+	}
+
+	if number, err := strconv.ParseFloat(value, 64); err == nil {
+		return number
+	}
+	return value
+}
+
+func findClientByName(room *Room, name string) *Client {
+	// mutex is already locked by caller function
+	for _, c := range room.Clients {
+		if name != "" && strings.EqualFold(c.Username, name) {
+			return c
+		}
+	}
+	return nil
+}
+
+// kickClient disconnects a player for good, they can't use their reconnect
+// token to come back
+func kickClient(room *Room, target *Client) {
+	// mutex is already locked by caller function
+	target.Kicked = true
+	clientLogf(target, "🥾 %s [%s] was kicked\n", target.Username, target.ID)
+
+	sendChatMessage(target, systemMessage(room, "youWereKicked"))
+
+	broadcastChatMessage(room, systemMessage(room, "playerKicked", target.Username))
+	broadcastEvent(room, "playerKicked", map[string]interface{}{
+		"playerId": target.ID,
+		"username": target.Username,
+	})
+
+	// Closing the socket ends the read loop, which removes the client
+	target.Conn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "kicked"),
+		time.Now().Add(time.Second))
+	target.Conn.Close()
+}
+
+// commandReply answers a command privately, with an error or a confirmation
+func commandReply(room *Room, client *Client, key string, args ...interface{}) {
+	// mutex is already locked by caller function
+	sendChatMessage(client, systemMessage(room, key, args...))
+}
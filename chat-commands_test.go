@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+// testConn dials a server that drops whatever it is sent, so commands have
+// a socket to reply on
+func testConn(t *testing.T) *websocket.Conn {
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func testRoomWithOwner(t *testing.T) (*Room, *Client) {
+	owner := &Client{ID: "owner", Username: "Owner", Type: "owner", Conn: testConn(t)}
+	room := &Room{
+		Clients:   map[string]*Client{owner.ID: owner},
+		GameState: &GameState{},
+		Settings:  defaultSettings(),
+	}
+	return room, owner
+}
+
+func TestSettingsCommandHints(t *testing.T) {
+	room, owner := testRoomWithOwner(t)
+
+	handleChatCommand(room, owner, "/settings hints none")
+	if room.Settings.HintPolicy != HintNone {
+		t.Fatalf("hints none: got policy %q, want %q", room.Settings.HintPolicy, HintNone)
+	}
+
+	handleChatCommand(room, owner, "/settings hints timed")
+	if room.Settings.HintPolicy != HintTimed {
+		t.Fatalf("hints timed: got policy %q, want %q", room.Settings.HintPolicy, HintTimed)
+	}
+
+	handleChatCommand(room, owner, "/settings hints off")
+	if room.Settings.HintPolicy != HintTimed {
+		t.Fatalf("hints off: got policy %q, want it left at %q", room.Settings.HintPolicy, HintTimed)
+	}
+}
+
+func TestSettingsCommandTextGuard(t *testing.T) {
+	room, owner := testRoomWithOwner(t)
+
+	handleChatCommand(room, owner, "/settings textguard block")
+	if room.Settings.TextGuard != TextGuardBlock {
+		t.Fatalf("textguard block: got %q, want %q", room.Settings.TextGuard, TextGuardBlock)
+	}
+
+	handleChatCommand(room, owner, "/settings textguard off")
+	if room.Settings.TextGuard != TextGuardOff {
+		t.Fatalf("textguard off: got %q, want it off", room.Settings.TextGuard)
+	}
+
+	handleChatCommand(room, owner, "/settings textguard flag")
+	handleChatCommand(room, owner, "/settings textguard none")
+	if room.Settings.TextGuard != TextGuardOff {
+		t.Fatalf("textguard none: got %q, want it off", room.Settings.TextGuard)
+	}
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultChatRetention = 72 // Hours chat is kept for moderators
+	chatPurgeInterval    = time.Hour
+	defaultChatFetch     = 200 // Entries returned when no limit is given
+	defaultChatEntries   = 10000
+	chatFlushInterval    = time.Second
+)
+
+// ChatLogEntry is one chat line a player sent
+type ChatLogEntry struct {
+	PlayerID string    `json:"playerId"`
+	Username string    `json:"username"`
+	Message  string    `json:"message"`
+	Time     time.Time `json:"time"`
+}
+
+// ChatLog keeps the room's chat for moderators to review reports against.
+// Entries older than the retention period are purged every hour, and only
+// the latest maxEntries are kept. With a path it is also appended to that
+// file as JSON lines, so it survives restarts. Lines are buffered and
+// written every chatFlushInterval, so a chat message never waits on disk
+type ChatLog struct {
+	mu         sync.Mutex
+	path       string
+	retention  time.Duration
+	maxEntries int
+	entries    []ChatLogEntry
+	pending    []byte // Lines not written to the file yet
+
+	fileMu sync.Mutex // Taken after mu, held while writing the file
+	file   *os.File
+}
+
+var chatLog = openChatLog(
+	os.Getenv("CHAT_LOG_FILE"),
+	time.Duration(envInt("CHAT_LOG_RETENTION_HOURS", defaultChatRetention))*time.Hour,
+	envInt("CHAT_LOG_MAX_ENTRIES", defaultChatEntries),
+)
+
+// openChatLog loads what is left of a saved chat log and starts purging it
+func openChatLog(path string, retention time.Duration, maxEntries int) *ChatLog {
+	chat := &ChatLog{
+		path:       path,
+		retention:  retention,
+		maxEntries: maxEntries,
+	}
+
+	if path != "" {
+		if file, err := os.Open(path); err == nil {
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				var entry ChatLogEntry
+				if json.Unmarshal(scanner.Bytes(), &entry) == nil {
+					chat.entries = append(chat.entries, entry)
+				}
+			}
+			file.Close()
+		}
+
+		chat.mu.Lock()
+		if err := chat.rewrite(); err != nil {
+			log.Printf("⚠️ Chat log is only kept in memory, failed to write %s: %v\n", path, err)
+		}
+		chat.mu.Unlock()
+	}
+
+	go chat.purgeLoop()
+	if path != "" {
+		go chat.flushLoop()
+	}
+	return chat
+}
+
+func (l *ChatLog) Append(entry ChatLogEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries = append(l.entries, entry)
+	if len(l.entries) > l.maxEntries {
+		l.entries = l.entries[len(l.entries)-l.maxEntries:]
+	}
+	if l.path == "" {
+		return
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		logError("chat", "Failed to write chat log", err)
+		return
+	}
+	l.pending = append(append(l.pending, line...), '\n')
+}
+
+func (l *ChatLog) flushLoop() {
+	ticker := time.NewTicker(chatFlushInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		l.Flush()
+	}
+}
+
+// Flush writes the buffered lines to the file. It takes fileMu before
+// letting go of mu, so a rewrite can't come in between and the lines land
+// in the file they were meant for
+func (l *ChatLog) Flush() {
+	l.mu.Lock()
+	l.fileMu.Lock()
+	pending := l.pending
+	l.pending = nil
+	l.mu.Unlock()
+	defer l.fileMu.Unlock()
+
+	if len(pending) == 0 || l.file == nil {
+		return
+	}
+	if _, err := l.file.Write(pending); err != nil {
+		logError("chat", "Failed to write chat log", err)
+	}
+}
+
+// Recent returns the latest entries, oldest first, optionally only those
+// sent by one player
+func (l *ChatLog) Recent(playerID string, limit int) []ChatLogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := []ChatLogEntry{}
+	for i := len(l.entries) - 1; i >= 0 && len(entries) < limit; i-- {
+		if playerID == "" || l.entries[i].PlayerID == playerID {
+			entries = append(entries, l.entries[i])
+		}
+	}
+
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	return entries
+}
+
+// DeletePlayer removes everything a player said from the log
+func (l *ChatLog) DeletePlayer(playerID string) error {
+	return l.remove(func(entry ChatLogEntry) bool {
+		return entry.PlayerID == playerID
+	})
+}
+
+func (l *ChatLog) purgeLoop() {
+	ticker := time.NewTicker(chatPurgeInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		cutoff := time.Now().Add(-l.retention)
+		err := l.remove(func(entry ChatLogEntry) bool {
+			return entry.Time.Before(cutoff)
+		})
+		if err != nil {
+			logError("chat", "Failed to purge chat log", err)
+		}
+	}
+}
+
+// remove drops the matching entries, rewriting the file if any went
+func (l *ChatLog) remove(match func(ChatLogEntry) bool) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	kept := []ChatLogEntry{}
+	for _, entry := range l.entries {
+		if !match(entry) {
+			kept = append(kept, entry)
+		}
+	}
+	if len(kept) == len(l.entries) {
+		return nil
+	}
+
+	l.entries = kept
+	if l.path == "" {
+		return nil
+	}
+	return l.rewrite()
+}
+
+// rewrite replaces the file with the entries still within the retention
+// period, writing a temporary file first so a crash never loses the log.
+// Buffered lines are among the entries, so they are written too
+func (l *ChatLog) rewrite() error {
+	// mutex is already locked by caller function
+	l.fileMu.Lock()
+	defer l.fileMu.Unlock()
+
+	cutoff := time.Now().Add(-l.retention)
+	kept := []ChatLogEntry{}
+	for _, entry := range l.entries {
+		if !entry.Time.Before(cutoff) {
+			kept = append(kept, entry)
+		}
+	}
+	l.entries = kept[max(0, len(kept)-l.maxEntries):]
+	l.pending = nil
+
+	tmp := l.path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	writer := bufio.NewWriter(file)
+	for _, entry := range l.entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			continue
+		}
+		writer.Write(append(line, '\n'))
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	file.Close()
+
+	if err := os.Rename(tmp, l.path); err != nil {
+		return err
+	}
+
+	if l.file != nil {
+		l.file.Close()
+	}
+	l.file, err = os.OpenFile(l.path, os.O_APPEND|os.O_WRONLY, 0644)
+	return err
+}
+
+// logChat records a player's chat line for moderators
+func logChat(client *Client, message string) {
+	chatLog.Append(ChatLogEntry{
+		PlayerID: client.ID,
+		Username: client.Username,
+		Message:  message,
+		Time:     time.Now(),
+	})
+}
+
+// chatLogHandler returns the room's recent chat, optionally only what the
+// player given as "player" said, up to "limit" entries
+func chatLogHandler(c *gin.Context) {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultChatFetch
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"messages": chatLog.Recent(c.Query("player"), limit),
+	})
+}
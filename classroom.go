@@ -0,0 +1,42 @@
+package main
+
+import "log"
+
+// classroomDrawer is who draws every turn in classroom mode: the player the
+// owner picked, or the owner while nobody they picked is here to draw
+func classroomDrawer(room *Room) string {
+	// mutex is already locked by caller function
+	if c, ok := room.Clients[room.FixedDrawer]; ok && c.Type != "spectator" {
+		return c.ID
+	}
+
+	for _, c := range room.Clients {
+		if c.Type == "owner" {
+			return c.ID
+		}
+	}
+	return ""
+}
+
+// setFixedDrawer lets the owner hand the pen to someone else in classroom
+// mode. It takes effect from the next turn
+func setFixedDrawer(room *Room, data map[string]interface{}) {
+	// mutex is already locked by caller function
+	playerID, _ := data["playerId"].(string)
+	drawer, ok := room.Clients[playerID]
+	if !ok || drawer.Type == "spectator" {
+		return
+	}
+
+	room.FixedDrawer = drawer.ID
+	log.Printf("🧑‍🏫 %s is the drawer now\n", drawer.Username)
+
+	broadcastChatMessage(room, systemMessage(room, "fixedDrawer", drawer.Username))
+	broadcastEvent(room, "fixedDrawerChanged", map[string]interface{}{
+		"playerId": drawer.ID,
+	})
+	if room.GameState.IsActive {
+		room.GameState.UpNext = upcomingDrawers(room)
+		broadcastGameState(room)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// startRoundClock starts the round's clock with the full round time on it
+func startRoundClock(room *Room) {
+	// mutex is already locked by caller function
+	room.RoundStartTime = time.Now()
+	room.RoundDeadline = room.RoundStartTime.Add(time.Duration(room.GameState.RoundTime) * time.Second)
+	room.GameState.RoundEndsAt = roundEndsAt(room)
+}
+
+// moveDeadline pushes the end of the round back, or pulls it in with a
+// negative duration
+func moveDeadline(room *Room, by time.Duration) {
+	// mutex is already locked by caller function
+	room.RoundDeadline = room.RoundDeadline.Add(by)
+	room.GameState.RoundEndsAt = roundEndsAt(room)
+}
+
+// roundEndsAt returns when the current round runs out, in unix milliseconds
+func roundEndsAt(room *Room) int64 {
+	// mutex is already locked by caller function
+	return room.RoundDeadline.UnixMilli()
+}
+
+// roundRemaining returns the whole seconds left in the round, rounded up
+func roundRemaining(room *Room) int {
+	// mutex is already locked by caller function
+	return int(math.Ceil(time.Until(room.RoundDeadline).Seconds()))
+}
+
+// sendServerTime answers a timeSync request, echoing the client's own send
+// time so it can work out both the round trip and its clock offset
+func sendServerTime(client *Client, clientTime interface{}) {
+	sendMessage(client, Message{
+		Type: "time",
+		Data: map[string]interface{}{
+			"clientTime": clientTime,
+			"serverTime": time.Now().UnixMilli(),
+		},
+	})
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	defaultShutdownGrace = 30 // Seconds players get to finish up before a shutdown
+	shutdownDrainTimeout = 10 * time.Second
+)
+
+// server is the HTTP server main runs, kept so the console can stop it
+var server *http.Server
+
+// stopped is closed once a shutdown has finished, main waits on it
+var stopped = make(chan struct{})
+
+var shuttingDown atomic.Bool
+
+// startConsole takes operator commands on stdin when it is a terminal, and
+// on the unix socket at ADMIN_SOCKET if set, e.g. with
+// "socat - UNIX-CONNECT:/run/skribbl.sock"
+func startConsole() {
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		go runConsole(os.Stdin, os.Stdout)
+	}
+
+	path := os.Getenv("ADMIN_SOCKET")
+	if path == "" {
+		return
+	}
+
+	// A socket left behind by an earlier run is in the way
+	os.Remove(path)
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		log.Printf("⚠️ Admin console socket unavailable: %v\n", err)
+		return
+	}
+	os.Chmod(path, 0600)
+	log.Printf("🖥️ Admin console listening on %s\n", path)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				runConsole(conn, conn)
+			}()
+		}
+	}()
+}
+
+// runConsole reads commands line by line until the input ends
+func runConsole(in io.Reader, out io.Writer) {
+	fmt.Fprintln(out, "Admin console, type help for commands")
+	scanner := bufio.NewScanner(in)
+	for fmt.Fprint(out, "> "); scanner.Scan(); fmt.Fprint(out, "> ") {
+		command, args, _ := strings.Cut(strings.TrimSpace(scanner.Text()), " ")
+		args = strings.TrimSpace(args)
+
+		switch strings.ToLower(command) {
+		case "":
+		case "help":
+			fmt.Fprintln(out, "rooms                      list rooms here and on registered instances")
+			fmt.Fprintln(out, "players                    list who is in the room")
+			fmt.Fprintln(out, "kick <room> <player>       kick a player by name or ID")
+			fmt.Fprintln(out, "broadcast <message>        send a message to everyone")
+			fmt.Fprintln(out, "shutdown [--grace <secs>]  warn players, then stop the server")
+		case "rooms":
+			consoleRooms(out)
+		case "players":
+			consolePlayers(out)
+		case "kick":
+			consoleKick(out, args)
+		case "broadcast":
+			consoleBroadcast(out, args)
+		case "shutdown":
+			consoleShutdown(out, args)
+		default:
+			fmt.Fprintf(out, "unknown command %q, type help for commands\n", command)
+		}
+	}
+}
+
+func consoleRooms(out io.Writer) {
+	room.mu.RLock()
+	listing := roomListing(room)
+	round, rounds := room.GameState.RoundNumber, room.GameState.TotalRounds
+	room.mu.RUnlock()
+
+	table := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "CODE\tHOST\tLANGUAGE\tPLAYERS\tSPECTATORS\tGAME")
+	game := "lobby"
+	if listing.IsActive {
+		game = fmt.Sprintf("round %d/%d", round, rounds)
+	}
+	fmt.Fprintf(table, "%s\t%s\t%s\t%d\t%d\t%s\n", listing.Code, "here", listing.Language, listing.Players, listing.Spectators, game)
+
+	for _, remote := range federation.Listings() {
+		game := "lobby"
+		if remote.IsActive {
+			game = "playing"
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%d\t%d\t%s\n", remote.Code, remote.Host, remote.Language, remote.Players, remote.Spectators, game)
+	}
+	table.Flush()
+}
+
+func consolePlayers(out io.Writer) {
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+
+	table := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "ID\tNAME\tTYPE\tSCORE")
+	for _, c := range room.Clients {
+		fmt.Fprintf(table, "%s\t%s\t%s\t%d\n", c.ID, c.Username, c.Type, room.Scores.Score(c.ID))
+	}
+	table.Flush()
+}
+
+func consoleKick(out io.Writer, args string) {
+	code, player, _ := strings.Cut(args, " ")
+	player = strings.TrimSpace(player)
+	if code == "" || player == "" {
+		fmt.Fprintln(out, "usage: kick <room> <player>")
+		return
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if !strings.EqualFold(code, room.Code) {
+		fmt.Fprintf(out, "no room %s here\n", code)
+		return
+	}
+
+	target, ok := room.Clients[player]
+	if !ok {
+		target = findClientByName(room, player)
+	}
+	if target == nil {
+		fmt.Fprintf(out, "no player %s in room %s\n", player, room.Code)
+		return
+	}
+
+	if beginBatch(room) {
+		defer flushBatch(room)
+	}
+	kickClient(room, target)
+	fmt.Fprintf(out, "kicked %s\n", target.Username)
+}
+
+func consoleBroadcast(out io.Writer, message string) {
+	if message == "" {
+		fmt.Fprintln(out, "usage: broadcast <message>")
+		return
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if beginBatch(room) {
+		defer flushBatch(room)
+	}
+	broadcastChatMessage(room, ChatMessage{
+		Username: "Admin",
+		Message:  message,
+		IsSystem: true,
+	})
+	log.Printf("📢 Admin broadcast: %s\n", message)
+	fmt.Fprintf(out, "sent to %d clients\n", len(room.Clients))
+}
+
+func consoleShutdown(out io.Writer, args string) {
+	grace := defaultShutdownGrace
+	if value, ok := strings.CutPrefix(args, "--grace"); ok {
+		seconds, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(value, "=")))
+		if err != nil || seconds < 0 {
+			fmt.Fprintln(out, "usage: shutdown [--grace <seconds>]")
+			return
+		}
+		grace = seconds
+	} else if args != "" {
+		fmt.Fprintln(out, "usage: shutdown [--grace <seconds>]")
+		return
+	}
+
+	if !shuttingDown.CompareAndSwap(false, true) {
+		fmt.Fprintln(out, "already shutting down")
+		return
+	}
+	fmt.Fprintf(out, "shutting down in %d seconds\n", grace)
+	go shutdownServer(time.Duration(grace) * time.Second)
+}
+
+// shutdownServer warns everyone, gives them the grace period to wrap up,
+// then closes the room and stops the server
+func shutdownServer(grace time.Duration) {
+	log.Printf("🛑 Shutting down in %s\n", grace)
+
+	room.mu.Lock()
+	beginBatch(room)
+	broadcastChatMessage(room, systemMessage(room, "serverShutdown", int(grace.Seconds())))
+	broadcastEvent(room, "serverShutdown", map[string]interface{}{
+		"seconds": int(grace.Seconds()),
+		"at":      time.Now().Add(grace).UnixMilli(),
+	})
+	flushBatch(room)
+	room.mu.Unlock()
+
+	time.Sleep(grace)
+
+	room.mu.Lock()
+	closeRoom(room)
+	room.mu.Unlock()
+	wordStore.Flush()
+	chatLog.Flush()
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownDrainTimeout)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Printf("⚠️ Server didn't stop cleanly: %v\n", err)
+	}
+	close(stopped)
+}
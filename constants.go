@@ -26,3 +26,25 @@ var Words = []string{
 	"woodpecker", "hummingbird", "robin", "sparrow", "finch", "canary", "pigeon", "dove", "crow", "raven",
 	"magpie", "jay", "starling", "blackbird", "thrush", "warbler", "wren", "nuthatch", "titmouse", "chickadee",
 }
+
+var WordsES = []string{
+	"gato", "perro", "casa", "árbol", "coche", "teléfono", "ordenador", "libro", "pizza", "guitarra",
+	"bicicleta", "flor", "nube", "montaña", "océano", "cohete", "arcoíris", "elefante", "mariposa", "castillo",
+	"dragón", "mago", "robot", "astronauta", "pingüino", "delfín", "faro", "volcán", "pirámide", "telescopio",
+	"submarino", "selva", "desierto", "isla", "cascada", "bosque", "pantano", "glaciar", "violín", "trompeta",
+	"tambor", "piano", "micrófono", "auriculares", "cámara", "televisión", "avión", "helicóptero", "tren", "autobús",
+	"moto", "patinete", "monopatín", "canoa", "tienda de campaña", "hoguera", "brújula", "mapa", "mochila", "linterna",
+	"baloncesto", "fútbol", "tenis", "béisbol", "golf", "ajedrez", "rompecabezas", "montaña rusa", "noria", "circo",
+	"fuegos artificiales", "globo", "cometa", "muñeco de nieve", "trineo", "iglú", "cabaña", "puente", "túnel", "fuente",
+	"estatua", "museo", "teatro", "concierto", "mercado", "restaurante", "panadería", "biblioteca", "escuela", "hospital",
+	"nave espacial", "extraterrestre", "planeta", "estrella", "galaxia", "máquina del tiempo", "superhéroe", "pirata", "ninja", "caballero",
+	"princesa", "rey", "reina", "sirena", "hada", "gigante", "bruja", "vampiro", "fantasma", "esqueleto",
+	"momia", "mariquita", "hormiga", "araña", "caracol", "gusano", "abeja", "mosquito", "escorpión", "avestruz",
+	"flamenco", "pavo real", "cisne", "pelícano", "gaviota", "águila", "halcón", "búho", "loro", "tucán",
+}
+
+// Built-in word packs by language, the word store starts out with these
+var WordPacks = map[string][]string{
+	"en": Words,
+	"es": WordsES,
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"time"
+)
+
+const (
+	maxWrongGuesses = 8                // Wrong guesses allowed inside the window
+	guessWindow     = 10 * time.Second // Window the wrong guesses are counted in
+	guessCooldown   = 5 * time.Second  // How long a flooding guesser has to wait
+)
+
+// recordWrongGuess remembers a missed guess and puts the client on a short
+// cooldown when they flood the chat with guesses
+func recordWrongGuess(client *Client) {
+	// mutex is already locked by caller function
+	now := time.Now()
+
+	// Drop guesses that fell out of the window
+	recent := client.WrongGuesses[:0]
+	for _, t := range client.WrongGuesses {
+		if now.Sub(t) < guessWindow {
+			recent = append(recent, t)
+		}
+	}
+	client.WrongGuesses = append(recent, now)
+
+	if len(client.WrongGuesses) <= maxWrongGuesses {
+		return
+	}
+
+	client.WrongGuesses = nil
+	client.CooldownUntil = now.Add(guessCooldown)
+	clientLogf(client, "🧊 %s is guessing too fast, cooling down\n", client.Username)
+
+	sendChatMessage(client, systemMessage(room, "tooManyGuesses", int(guessCooldown.Seconds())))
+	sendEvent(room, client, "guessCooldown", map[string]interface{}{
+		"until": client.CooldownUntil.UnixMilli(),
+	})
+}
+
+// isCoolingDown reports whether the client is waiting out a guess cooldown
+func isCoolingDown(client *Client) bool {
+	return time.Now().Before(client.CooldownUntil)
+}
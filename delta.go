@@ -0,0 +1,346 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+)
+
+// Every this many updates clients get the full game state again, so a
+// delta lost to a client bug can't leave it out of sync for long
+const fullStateInterval = 10
+
+// gameStateUpdate marshals the public game state once for the whole room.
+// It returns the update to broadcast, which is only the fields changed since
+// the last broadcast unless a full snapshot is due, and the full snapshot for
+// clients that don't have the previous state yet. update is nil if nothing
+// changed
+func gameStateUpdate(room *Room) (update []byte, full []byte) {
+	// mutex is already locked by caller function
+	state := publicGameState(room)
+
+	raw, err := json.Marshal(&state)
+	if err != nil {
+		captureError("marshal", err, map[string]interface{}{
+			"type": "gameState",
+		})
+		return nil, nil
+	}
+
+	// The fields of the last broadcast are kept until the next one, so the
+	// slice of the one before is free to fill again
+	fields, ok := splitFields(raw, room.LastState, room.spareState[:0])
+	if !ok {
+		return nil, nil
+	}
+
+	full = gameStateFrame(raw)
+	update = full
+
+	if room.LastState != nil && room.StateUpdates%fullStateInterval != 0 {
+		update = gameStateDelta(room.LastState, fields)
+	}
+
+	room.spareState = room.LastState
+	room.LastState = fields
+	room.StateUpdates++
+	return update, full
+}
+
+// stateField is one top-level field of the marshaled game state, its value
+// pointing into the marshaled bytes rather than a copy of them
+type stateField struct {
+	key   string
+	value json.RawMessage
+}
+
+// splitFields appends the top-level fields of a compact JSON object, as
+// json.Marshal writes it, to fields. Keys are taken from prev where they
+// match, so a steady game state splits without allocating. It reports false
+// if raw isn't an object
+func splitFields(raw []byte, prev []stateField, fields []stateField) ([]stateField, bool) {
+	if len(raw) < 2 || raw[0] != '{' {
+		return nil, false
+	}
+
+	for i := 1; i < len(raw) && raw[i] != '}'; {
+		if raw[i] != '"' {
+			return nil, false
+		}
+		keyEnd := jsonValueEnd(raw, i)
+		if keyEnd < 0 || keyEnd >= len(raw) || raw[keyEnd] != ':' {
+			return nil, false
+		}
+		valueEnd := jsonValueEnd(raw, keyEnd+1)
+		if valueEnd < 0 {
+			return nil, false
+		}
+
+		keyBytes := raw[i+1 : keyEnd-1]
+		key := ""
+		for _, field := range prev {
+			if field.key == string(keyBytes) {
+				key = field.key
+				break
+			}
+		}
+		if key == "" {
+			key = string(keyBytes)
+		}
+
+		fields = append(fields, stateField{key: key, value: raw[keyEnd+1 : valueEnd]})
+		i = valueEnd
+		if i < len(raw) && raw[i] == ',' {
+			i++
+		}
+	}
+	return fields, true
+}
+
+// jsonValueEnd returns the index just past the JSON value that starts at
+// i, or -1 if it doesn't end
+func jsonValueEnd(raw []byte, i int) int {
+	depth, inString := 0, false
+	for start := i; i < len(raw); i++ {
+		c := raw[i]
+		if inString {
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+				if depth == 0 {
+					return i + 1
+				}
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+		case '}', ']':
+			if depth == 0 {
+				return i
+			}
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		case ',', ':':
+			if depth == 0 && i > start {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// gameStateFrame wraps the marshaled state in a gameState message, built
+// straight into a buffer of the exact size
+func gameStateFrame(raw []byte) []byte {
+	const prefix = `{"type":"gameState","data":`
+
+	frame := make([]byte, 0, len(prefix)+len(raw)+1)
+	frame = append(frame, prefix...)
+	frame = append(frame, raw...)
+	return append(frame, '}')
+}
+
+// gameStateDelta builds a gameStateDelta message of the fields that changed
+// since the last broadcast, or returns nil if none did. Fields left out by
+// omitempty were cleared and go out as null
+func gameStateDelta(last []stateField, fields []stateField) []byte {
+	const prefix = `{"type":"gameStateDelta","data":{`
+
+	// Sized up front so the frame is built in a single allocation
+	size, changed := len(prefix)+2, 0
+	for _, field := range fields {
+		if value, ok := stateValue(last, field.key); !ok || !bytes.Equal(value, field.value) {
+			size += len(field.key) + len(field.value) + 4
+			changed++
+		}
+	}
+	for _, field := range last {
+		if _, ok := stateValue(fields, field.key); !ok {
+			size += len(field.key) + len("null") + 4
+			changed++
+		}
+	}
+	if changed == 0 {
+		return nil
+	}
+
+	frame := make([]byte, 0, size)
+	frame = append(frame, prefix...)
+	for _, field := range fields {
+		if value, ok := stateValue(last, field.key); !ok || !bytes.Equal(value, field.value) {
+			frame = appendStateField(frame, len(frame) > len(prefix), field.key, field.value)
+		}
+	}
+	for _, field := range last {
+		if _, ok := stateValue(fields, field.key); !ok {
+			frame = appendStateField(frame, len(frame) > len(prefix), field.key, []byte("null"))
+		}
+	}
+	return append(frame, '}', '}')
+}
+
+// appendStateField appends "key":value to an object being built, after a
+// comma unless it's the first field
+func appendStateField(frame []byte, comma bool, key string, value []byte) []byte {
+	if comma {
+		frame = append(frame, ',')
+	}
+	frame = append(frame, '"')
+	frame = append(frame, key...)
+	frame = append(frame, '"', ':')
+	return append(frame, value...)
+}
+
+// stateValue looks a field up by key
+func stateValue(fields []stateField, key string) (json.RawMessage, bool) {
+	for _, field := range fields {
+		if field.key == key {
+			return field.value, true
+		}
+	}
+	return nil, false
+}
+
+// timerFields are the game state fields the drawer doesn't get when the
+// room hides the clock from them
+var timerFields = []string{"timeRemaining", "roundEndsAt"}
+
+// ClientView is what one client gets to see of the game on top of the
+// public state the whole room shares
+type ClientView struct {
+	HideTimer bool                   // The drawer plays without the clock
+	Private   map[string]interface{} // Sent in yourWord, see sendPrivateView
+}
+
+// buildClientView works out the client's own view of the game: the word
+// and bonus word for the drawer and spectators, the word alone for players
+// who guessed it, a hint improved by a power-up, and the word choices for
+// the drawer while they pick
+func buildClientView(room *Room, client *Client) ClientView {
+	// mutex is already locked by caller function
+	state := room.GameState
+	drawer := client.ID == state.CurrentDrawer
+
+	view := ClientView{
+		HideTimer: room.Settings.HideDrawerTimer && drawer,
+		Private:   map[string]interface{}{},
+	}
+
+	if drawer || client.Type == "spectator" {
+		view.Private["word"] = state.CurrentWord
+		view.Private["bonusWord"] = state.CurrentBonusWord
+	} else if state.PlayersGuessed[client.ID] {
+		// The bonus word stays hidden, they can still find it
+		view.Private["word"] = state.CurrentWord
+	} else if hint, ok := state.PersonalHints[client.ID]; ok {
+		view.Private["hint"] = hint
+	}
+
+	if drawer && len(state.WordChoices) > 0 {
+		view.Private["wordChoices"] = append([]string{}, state.WordChoices...)
+	}
+	return view
+}
+
+// messageKeys are the keys of a marshaled Message, so splitting one doesn't
+// allocate them
+var messageKeys = []stateField{{key: "type"}, {key: "data"}}
+
+// withoutTimer strips the timer fields from a gameState or gameStateDelta
+// message, a delta with nothing else left in it becomes nil. keys are the
+// fields of the last broadcast, whose keys the split reuses
+func withoutTimer(jsonData []byte, keys []stateField) []byte {
+	if jsonData == nil {
+		return nil
+	}
+
+	message, ok := splitFields(jsonData, messageKeys, make([]stateField, 0, len(messageKeys)))
+	msgType, hasType := stateValue(message, "type")
+	data, hasData := stateValue(message, "data")
+	if !ok || !hasType || !hasData || len(message) != len(messageKeys) {
+		return jsonData
+	}
+
+	fields, ok := splitFields(data, keys, make([]stateField, 0, len(keys)))
+	if !ok {
+		return jsonData
+	}
+
+	kept := fields[:0]
+	for _, field := range fields {
+		if !slices.Contains(timerFields, field.key) {
+			kept = append(kept, field)
+		}
+	}
+	if len(kept) == len(fields) {
+		return jsonData
+	}
+	if len(kept) == 0 && string(msgType) == `"gameStateDelta"` {
+		return nil
+	}
+
+	const prefix, middle = `{"type":`, `,"data":{`
+	size := len(prefix) + len(msgType) + len(middle) + 2
+	for _, field := range kept {
+		size += len(field.key) + len(field.value) + 4
+	}
+
+	frame := make([]byte, 0, size)
+	frame = append(frame, prefix...)
+	frame = append(frame, msgType...)
+	frame = append(frame, middle...)
+	for i, field := range kept {
+		frame = appendStateField(frame, i > 0, field.key, field.value)
+	}
+	return append(frame, '}', '}')
+}
+
+// publicGameState returns a copy of the game state as every client may see
+// it. The word choices only go to the drawer, in yourWord, and fields hidden
+// from clients are dropped so the copy shares none of the room's maps
+func publicGameState(room *Room) GameState {
+	// mutex is already locked by caller function
+	state := *room.GameState
+	state.CurrentWord = ""
+	state.CurrentBonusWord = ""
+	state.WordChoices = nil
+	state.UpNext = append([]string(nil), state.UpNext...)
+	state.PlayersGuessed = nil
+	state.PersonalHints = nil
+	state.GuessTimes = nil
+	state.GuessOrder = nil
+	state.SmallStrokes = nil
+	return state
+}
+
+// sendPrivateView sends the client a yourWord message with the private part
+// of its view. Nothing is sent if it hasn't changed since last time
+func sendPrivateView(client *Client, view ClientView) {
+	jsonData, err := json.Marshal(Message{
+		Type: "yourWord",
+		Data: view.Private,
+	})
+	if err != nil {
+		captureError("marshal", err, map[string]interface{}{
+			"type": "yourWord",
+		})
+		return
+	}
+
+	if bytes.Equal(jsonData, client.PrivateView) {
+		return
+	}
+
+	if err := writeToClient(client, jsonData); err != nil {
+		return
+	}
+	client.PrivateView = jsonData
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// BenchmarkBroadcastGameState measures one clock tick of 100 rooms in the
+// middle of a turn, each hiding the clock from its drawer, so every tick
+// builds a delta, a full snapshot and the drawer's copies of both
+func BenchmarkBroadcastGameState(b *testing.B) {
+	rooms := make([]*Room, 100)
+	for i := range rooms {
+		rooms[i] = &Room{
+			Clients: make(map[string]*Client),
+			GameState: &GameState{
+				IsActive:      true,
+				Phase:         PhaseDrawing,
+				WordHint:      "_ _ _ _ _ _",
+				CurrentDrawer: "drawer",
+				TimeRemaining: 80,
+				RoundTime:     80,
+				RoundEndsAt:   time.Now().Add(80 * time.Second).UnixMilli(),
+				RoundNumber:   2,
+				TotalRounds:   3,
+				TurnNumber:    1,
+				TurnsInRound:  4,
+				UpNext:        []string{"first", "second", "third"},
+				HintPolicy:    "standard",
+				CanvasWidth:   defaultCanvasWidth,
+				CanvasHeight:  defaultCanvasHeight,
+			},
+			Settings: defaultSettings(),
+		}
+		rooms[i].Settings.HideDrawerTimer = true
+		broadcastGameState(rooms[i])
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, room := range rooms {
+			room.GameState.TimeRemaining--
+			broadcastGameState(room)
+		}
+	}
+}
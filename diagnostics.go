@@ -0,0 +1,123 @@
+package main
+
+import (
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RoutineCounter keeps count of a room's goroutines by what they are for,
+// so one kind piling up shows in the diagnostics
+type RoutineCounter struct {
+	mu      sync.Mutex
+	running map[string]int
+	started map[string]int
+}
+
+func (r *RoutineCounter) Start(where string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.running == nil {
+		r.running = make(map[string]int)
+		r.started = make(map[string]int)
+	}
+	r.running[where]++
+	r.started[where]++
+}
+
+func (r *RoutineCounter) Done(where string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.running[where]--
+}
+
+// Snapshot returns how many goroutines of each kind are running, and how
+// many were started since the server came up
+func (r *RoutineCounter) Snapshot() (running map[string]int, started map[string]int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	running, started = make(map[string]int), make(map[string]int)
+	for where, n := range r.running {
+		running[where] = n
+	}
+	for where, n := range r.started {
+		started[where] = n
+	}
+	return running, started
+}
+
+// diagnosticsHandler reports what the room has running, for spotting leaked
+// goroutines and timers in production: goroutines by kind against the
+// process total, pending timers and deadlines, and per client the frames
+// waiting in an open batch and failed writes. Writes aren't queued beyond
+// a batch, a stuck client shows as failing writes instead
+func diagnosticsHandler(c *gin.Context) {
+	running, started := room.Routines.Snapshot()
+	roomGoroutines := 0
+	for _, n := range running {
+		roomGoroutines += n
+	}
+
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+
+	pending := map[*Client]int{}
+	if batch := room.batch.Load(); batch != nil {
+		batch.mu.Lock()
+		for client, frames := range batch.frames {
+			pending[client] = len(frames)
+		}
+		batch.mu.Unlock()
+	}
+
+	clients := []gin.H{}
+	for _, client := range room.Clients {
+		clients = append(clients, gin.H{
+			"id":            client.ID,
+			"username":      client.Username,
+			"connectionId":  client.ConnID,
+			"pendingFrames": pending[client],
+			"writeErrors":   client.WriteErrors.Load(),
+			"latency":       client.Latency.Milliseconds(),
+		})
+	}
+
+	state := room.GameState
+	timers := gin.H{
+		"autoStart":       room.AutoStartTimer != nil,
+		"reconnectGraces": len(room.Departed),
+		"phase":           state.Phase,
+	}
+	if state.IsActive && !room.RoundDeadline.IsZero() {
+		timers["roundEndsAt"] = room.RoundDeadline.UnixMilli()
+	}
+	if state.IntermissionEndsAt != 0 {
+		timers["intermissionEndsAt"] = state.IntermissionEndsAt
+	}
+	if !room.DrawerLeftAt.IsZero() {
+		timers["drawerLeftAt"] = room.DrawerLeftAt.UnixMilli()
+	}
+	if !room.PlayingSince.IsZero() {
+		timers["playingFor"] = int(time.Since(room.PlayingSince).Seconds())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"goroutines": gin.H{
+			"total": runtime.NumGoroutine(),
+			"room":  roomGoroutines,
+		},
+		"room": gin.H{
+			"code":       room.Code,
+			"clients":    clients,
+			"goroutines": running,
+			"started":    started,
+			"timers":     timers,
+		},
+	})
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Definition explains a revealed word
+type Definition struct {
+	Text string `json:"definition,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
+// Dictionary looks up words for the reveal at the end of a turn. It is
+// asked with the room locked, so lookups must not wait on the network
+type Dictionary interface {
+	Define(language string, word string) (Definition, bool)
+}
+
+// dictionary is picked with DICTIONARY: "wiktionary" (the default) links
+// every word to Wiktionary, "none" turns definitions off. DICTIONARY_FILE
+// adds short definitions from a JSON file of language to word to text
+var dictionary = loadDictionary(os.Getenv("DICTIONARY"), os.Getenv("DICTIONARY_FILE"))
+
+func loadDictionary(provider string, path string) Dictionary {
+	if provider == "none" {
+		return nil
+	}
+
+	var links Dictionary = wiktionary{}
+	if path == "" {
+		return links
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("⚠️ Ignoring dictionary %s: %v\n", path, err)
+		return links
+	}
+
+	definitions := fileDictionary{fallback: links}
+	if err := json.Unmarshal(data, &definitions.words); err != nil {
+		log.Printf("⚠️ Ignoring unreadable dictionary %s: %v\n", path, err)
+		return links
+	}
+	return definitions
+}
+
+// wiktionary links a word to its Wiktionary page in the room's language
+type wiktionary struct{}
+
+func (wiktionary) Define(language string, word string) (Definition, bool) {
+	return Definition{
+		URL: fmt.Sprintf("https://%s.wiktionary.org/wiki/%s", language, url.PathEscape(strings.ReplaceAll(word, " ", "_"))),
+	}, true
+}
+
+// fileDictionary has short definitions loaded from a file, anything else
+// goes to the fallback
+type fileDictionary struct {
+	words    map[string]map[string]string
+	fallback Dictionary
+}
+
+func (d fileDictionary) Define(language string, word string) (Definition, bool) {
+	definition, _ := d.fallback.Define(language, word)
+	if text, ok := d.words[language][strings.ToLower(word)]; ok {
+		definition.Text = text
+	}
+	return definition, definition != Definition{}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	discordAPI          = "https://discord.com/api/v10"
+	discordQueueSize    = 200 // Chat lines waiting to go out before dropping
+	discordPollInterval = 3 * time.Second
+	discordTimeout      = 10 * time.Second
+	discordMaxContent   = 1900 // Discord takes 2000 characters per message
+	discordMaxRelay     = 300  // Longest Discord message relayed into the room
+)
+
+// DiscordBridge mirrors the room's public chat to a Discord channel, and
+// relays what is said in that channel to the room's spectators
+type DiscordBridge struct {
+	token     string
+	channelID string
+	botID     string
+	client    *http.Client
+	outbound  chan string
+}
+
+// discordMessage is the part of a Discord message object we use
+type discordMessage struct {
+	ID      string `json:"id"`
+	Content string `json:"content"`
+	Author  struct {
+		ID         string `json:"id"`
+		Username   string `json:"username"`
+		GlobalName string `json:"global_name"`
+		Bot        bool   `json:"bot"`
+	} `json:"author"`
+}
+
+// discord is set up with DISCORD_BOT_TOKEN and DISCORD_CHANNEL_ID, it is nil
+// when the bridge is off. The bot needs to read and send messages in the
+// channel, and the message content intent
+var discord = startDiscordBridge(os.Getenv("DISCORD_BOT_TOKEN"), os.Getenv("DISCORD_CHANNEL_ID"))
+
+func startDiscordBridge(token string, channelID string) *DiscordBridge {
+	if token == "" || channelID == "" {
+		return nil
+	}
+
+	bridge := &DiscordBridge{
+		token:     token,
+		channelID: channelID,
+		client:    &http.Client{Timeout: discordTimeout},
+		outbound:  make(chan string, discordQueueSize),
+	}
+
+	log.Printf("💬 Bridging chat with Discord channel %s\n", channelID)
+	go bridge.sendLoop()
+	go bridge.pollLoop()
+	return bridge
+}
+
+// mirrorToDiscord queues a chat message everyone in the room saw for the
+// Discord channel. It never blocks, lines are dropped when Discord falls
+// behind
+func mirrorToDiscord(chatMsg ChatMessage) {
+	if discord == nil {
+		return
+	}
+
+	line := "**" + escapeDiscord(chatMsg.Username) + "**: " + escapeDiscord(chatMsg.Message)
+	if chatMsg.IsSystem {
+		line = "*" + escapeDiscord(chatMsg.Message) + "*"
+	}
+
+	select {
+	case discord.outbound <- line:
+	default:
+	}
+}
+
+// escapeDiscord keeps chat from being read as Discord markdown
+func escapeDiscord(text string) string {
+	var escaped strings.Builder
+	for _, char := range text {
+		if strings.ContainsRune("\\*_~`|>#[]()-", char) {
+			escaped.WriteRune('\\')
+		}
+		escaped.WriteRune(char)
+	}
+	return escaped.String()
+}
+
+// sendLoop posts queued chat to the channel, joining whatever piled up into
+// as few messages as possible
+func (b *DiscordBridge) sendLoop() {
+	for line := range b.outbound {
+		content := line
+	batch:
+		for {
+			select {
+			case next := <-b.outbound:
+				if len(content)+1+len(next) > discordMaxContent {
+					b.post(content)
+					content = next
+					continue
+				}
+				content += "\n" + next
+			default:
+				break batch
+			}
+		}
+		b.post(content)
+	}
+}
+
+func (b *DiscordBridge) post(content string) {
+	if runes := []rune(content); len(runes) > discordMaxContent {
+		content = string(runes[:discordMaxContent])
+	}
+	body, _ := json.Marshal(map[string]interface{}{
+		"content": content,
+		// Nobody gets pinged by what is said in the room
+		"allowed_mentions": map[string]interface{}{"parse": []string{}},
+	})
+
+	for attempt := 0; attempt < 2; attempt++ {
+		retryAfter, err := b.request(http.MethodPost, "/channels/"+b.channelID+"/messages", body, nil)
+		if err == nil {
+			return
+		}
+		if retryAfter == 0 {
+			log.Printf("⚠️ Failed to mirror chat to Discord: %v\n", err)
+			return
+		}
+		time.Sleep(retryAfter)
+	}
+}
+
+// pollLoop relays new messages in the channel to the room, starting from
+// the latest one when the bridge comes up
+func (b *DiscordBridge) pollLoop() {
+	var me struct {
+		ID string `json:"id"`
+	}
+	if _, err := b.request(http.MethodGet, "/users/@me", nil, &me); err != nil {
+		log.Printf("⚠️ Discord bridge can't log in, only mirroring chat: %v\n", err)
+		return
+	}
+	b.botID = me.ID
+
+	after := ""
+	var latest []discordMessage
+	if _, err := b.request(http.MethodGet, "/channels/"+b.channelID+"/messages?limit=1", nil, &latest); err == nil && len(latest) > 0 {
+		after = latest[0].ID
+	}
+
+	for range time.Tick(discordPollInterval) {
+		path := "/channels/" + b.channelID + "/messages?limit=50"
+		if after != "" {
+			path += "&after=" + after
+		}
+
+		var messages []discordMessage
+		if retryAfter, err := b.request(http.MethodGet, path, nil, &messages); err != nil {
+			if retryAfter == 0 {
+				log.Printf("⚠️ Failed to read Discord messages: %v\n", err)
+			}
+			time.Sleep(retryAfter)
+			continue
+		}
+
+		// Snowflake IDs grow over time, oldest goes first
+		sort.Slice(messages, func(i, j int) bool {
+			return snowflake(messages[i].ID) < snowflake(messages[j].ID)
+		})
+		for _, message := range messages {
+			after = message.ID
+			if message.Author.Bot || message.Author.ID == b.botID || strings.TrimSpace(message.Content) == "" {
+				continue
+			}
+			relayFromDiscord(message)
+		}
+	}
+}
+
+func snowflake(id string) uint64 {
+	value, _ := strconv.ParseUint(id, 10, 64)
+	return value
+}
+
+// request calls the Discord API and decodes the answer into v, if given.
+// When rate limited it returns how long to wait before trying again
+func (b *DiscordBridge) request(method string, path string, body []byte, v interface{}) (time.Duration, error) {
+	request, err := http.NewRequest(method, discordAPI+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	request.Header.Set("Authorization", "Bot "+b.token)
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+
+	response, err := b.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusTooManyRequests {
+		var limited struct {
+			RetryAfter float64 `json:"retry_after"`
+		}
+		json.NewDecoder(response.Body).Decode(&limited)
+		return time.Duration(limited.RetryAfter*float64(time.Second)) + time.Millisecond, fmt.Errorf("rate limited")
+	}
+	if response.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(response.Body, 512))
+		return 0, fmt.Errorf("discord answered %s: %s", response.Status, message)
+	}
+
+	if v == nil {
+		return 0, nil
+	}
+	return 0, json.NewDecoder(response.Body).Decode(v)
+}
+
+// relayFromDiscord shows a Discord message to the room's spectators, who
+// have their own chat since they know the word
+func relayFromDiscord(message discordMessage) {
+	name := message.Author.GlobalName
+	if name == "" {
+		name = message.Author.Username
+	}
+
+	content := []rune(strings.TrimSpace(message.Content))
+	if len(content) > discordMaxRelay {
+		content = content[:discordMaxRelay]
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if beginBatch(room) {
+		defer flushBatch(room)
+	}
+	sendToSpectators(room, Message{
+		Type: "chat",
+		Data: ChatMessage{
+			Username: name + " (Discord)",
+			Message:  string(content),
+			Channel:  "discord",
+		},
+	})
+}
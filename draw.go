@@ -0,0 +1,367 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+)
+
+const (
+	defaultCanvasWidth  = 800
+	defaultCanvasHeight = 600
+	minCanvasSize       = 100
+	maxCanvasSize       = 4000
+
+	minBrushSize     = 1
+	maxBrushSize     = 60
+	maxStrokePoints  = 1000
+	maxStrokeLog     = 5000 // Draw operations kept since the canvas was last cleared
+	strokeBoundSlack = 1    // Rounding room for points right on the edge
+
+	keyframeInterval = 100             // Operations between keyframe requests
+	clearByServer    = "server"        // Who a clear is recorded as when the round engine wipes the canvas
+	maxKeyframeSize  = 2 * 1024 * 1024 // Longest accepted image data URL
+)
+
+// Colors a stroke may use
+var strokePalette = []string{
+	"#ffffff", "#c1c1c1", "#ef130b", "#ff7100", "#ffe400", "#00cc00", "#00b2ff", "#231fd3", "#a300ba", "#d37caa", "#a0522d",
+	"#000000", "#4c4c4c", "#740b07", "#c23800", "#e8a200", "#005510", "#00569e", "#0e0865", "#550069", "#a75574", "#63300d",
+}
+
+// Background templates the owner can lock under the drawing
+var backgroundTemplates = []string{"", "grid", "dots", "frame", "halves"}
+
+func isValidBackground(background string) bool {
+	for _, b := range backgroundTemplates {
+		if b == background {
+			return true
+		}
+	}
+	return false
+}
+
+// drawType returns the sub-type of a draw message, such as "stroke" or "clear"
+func drawType(data interface{}) string {
+	fields, ok := data.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	drawType, _ := fields["type"].(string)
+	return drawType
+}
+
+// handleDraw checks, normalizes and records a draw message from the current
+// drawer before relaying it to everyone else. Only known operation types
+// are accepted, anything else is dropped. Clearing the canvas is its own
+// message, see clearDrawing
+func handleDraw(room *Room, client *Client, message Message) {
+	// mutex is already locked by caller function
+	opType := drawType(message.Data)
+
+	// The clear marker the log starts with doesn't count towards the cap
+	if len(room.StrokeLog)-canvasStart(room) >= maxStrokeLog && opType != "undo" {
+		return
+	}
+
+	var op DrawOp
+	switch opType {
+	case "stroke", "erase":
+		// Strokes are scaled into the room's canvas space and checked
+		// before relaying, anything malformed is dropped
+		stroke, ok := parseStroke(message.Data)
+		if !ok {
+			return
+		}
+
+		normalizeStroke(room, &stroke)
+		if !validateStroke(room, stroke) {
+			clientLogf(client, "🚫 Dropped invalid stroke from %s\n", client.Username)
+			return
+		}
+		if checkTextStrokes(room, client, stroke) {
+			return
+		}
+		op = stroke
+
+	case "fill":
+		fill, ok := parseFill(message.Data)
+		if !ok {
+			return
+		}
+
+		normalizeFill(room, &fill)
+		if !validateFill(room, fill) {
+			clientLogf(client, "🚫 Dropped invalid fill from %s\n", client.Username)
+			return
+		}
+		op = fill
+
+	case "undo":
+		if len(room.StrokeLog) == 0 {
+			return
+		}
+
+		// The wipe at the start of the turn isn't the drawer's to take back
+		if wipe, ok := room.StrokeLog[len(room.StrokeLog)-1].(Clear); ok && wipe.By == clearByServer {
+			return
+		}
+
+		// Everyone redraws from the log rather than guessing what the
+		// last operation covered up
+		room.StrokeLog = room.StrokeLog[:len(room.StrokeLog)-1]
+		if room.KeyframeOps > len(room.StrokeLog) {
+			room.Keyframe = nil
+			room.KeyframeOps = 0
+		}
+		broadcastCanvas(room, client.ID)
+		recordReplay(room, "undo", nil)
+		return
+
+	case "keyframe":
+		storeKeyframe(room, message.Data)
+		return
+
+	case "clear":
+		clientLogf(client, "🚫 Dropped clear sent as a draw message by %s\n", client.Username)
+		return
+
+	default:
+		return
+	}
+
+	room.StrokeLog = append(room.StrokeLog, op)
+	recordReplay(room, "draw", op)
+
+	// Incremental operations are best-effort, a client that falls behind
+	// catches up from the latest keyframe with a canvasSync
+	broadcastToOthers(room, client.ID, Message{
+		Type: "draw",
+		Data: op,
+	})
+
+	requestKeyframe(room, client)
+}
+
+// clearDrawing wipes the canvas for the current drawer. It is only sent as
+// a dedicated "clear" message, never as draw data, so nobody else can clear
+// the canvas by sending something shaped like one
+func clearDrawing(room *Room, client *Client) {
+	// mutex is already locked by caller function
+
+	// Nothing before the clear is visible any more, so the log starts over
+	// from it and a drawer clearing over and over can't grow it
+	op := Clear{Type: "clear", By: client.ID}
+	room.StrokeLog = []DrawOp{op}
+	room.Keyframe = nil
+	room.KeyframeOps = len(room.StrokeLog)
+	recordReplay(room, "draw", op)
+
+	broadcastToOthers(room, client.ID, Message{
+		Type: "draw",
+		Data: op,
+	})
+}
+
+// decodeDrawData decodes the loosely typed data of a draw message into v
+func decodeDrawData(data interface{}, v interface{}) bool {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return false
+	}
+
+	return json.Unmarshal(raw, v) == nil
+}
+
+// parseStroke decodes the data of a draw message of type "stroke" or "erase"
+func parseStroke(data interface{}) (Stroke, bool) {
+	var stroke Stroke
+	if !decodeDrawData(data, &stroke) {
+		return stroke, false
+	}
+
+	return stroke, stroke.Type == "stroke" || stroke.Type == "erase"
+}
+
+// parseFill decodes the data of a draw message of type "fill"
+func parseFill(data interface{}) (Fill, bool) {
+	var fill Fill
+	if !decodeDrawData(data, &fill) {
+		return fill, false
+	}
+
+	return fill, fill.Type == "fill"
+}
+
+// validateStroke checks a normalized stroke is something a real client
+// could have drawn, so malformed or abusive strokes can be dropped
+func validateStroke(room *Room, stroke Stroke) bool {
+	// mutex is already locked by caller function
+	if len(stroke.Points) == 0 || len(stroke.Points) > maxStrokePoints {
+		return false
+	}
+
+	if math.IsNaN(stroke.Size) || stroke.Size < minBrushSize || stroke.Size > maxBrushSize {
+		return false
+	}
+
+	// The eraser has no color of its own
+	if stroke.Type == "stroke" && !isPaletteColor(stroke.Color) {
+		return false
+	}
+
+	width := float64(room.GameState.CanvasWidth) + strokeBoundSlack
+	height := float64(room.GameState.CanvasHeight) + strokeBoundSlack
+	for _, p := range stroke.Points {
+		if !(p.X >= -strokeBoundSlack && p.X <= width && p.Y >= -strokeBoundSlack && p.Y <= height) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func validateFill(room *Room, fill Fill) bool {
+	// mutex is already locked by caller function
+	if !isPaletteColor(fill.Color) {
+		return false
+	}
+
+	return fill.Point.X >= 0 && fill.Point.X <= float64(room.GameState.CanvasWidth) &&
+		fill.Point.Y >= 0 && fill.Point.Y <= float64(room.GameState.CanvasHeight)
+}
+
+func isPaletteColor(color string) bool {
+	for _, c := range strokePalette {
+		if strings.EqualFold(c, color) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeStroke scales a stroke drawn on the drawer's own canvas into the
+// room's canvas space so every client renders it the same
+func normalizeStroke(room *Room, stroke *Stroke) {
+	// mutex is already locked by caller function
+	width := float64(room.GameState.CanvasWidth)
+	height := float64(room.GameState.CanvasHeight)
+
+	if stroke.Width > 0 && stroke.Height > 0 {
+		scaleX := width / stroke.Width
+		scaleY := height / stroke.Height
+
+		for i := range stroke.Points {
+			stroke.Points[i].X *= scaleX
+			stroke.Points[i].Y *= scaleY
+		}
+
+		// A brush that was valid on the drawer's canvas stays valid after
+		// scaling, so thin lines don't vanish on big canvases
+		if stroke.Size >= minBrushSize && stroke.Size <= maxBrushSize {
+			size := stroke.Size * math.Min(scaleX, scaleY)
+			stroke.Size = math.Min(math.Max(size, minBrushSize), maxBrushSize)
+		}
+	}
+
+	stroke.Width = width
+	stroke.Height = height
+}
+
+// normalizeFill scales the fill point into the room's canvas space
+func normalizeFill(room *Room, fill *Fill) {
+	// mutex is already locked by caller function
+	width := float64(room.GameState.CanvasWidth)
+	height := float64(room.GameState.CanvasHeight)
+
+	if fill.Width > 0 && fill.Height > 0 {
+		fill.Point.X *= width / fill.Width
+		fill.Point.Y *= height / fill.Height
+	}
+
+	fill.Width = width
+	fill.Height = height
+}
+
+// canvasStart returns the index of the first logged operation after the
+// last clear. Clearing starts the log over, so a clear can only be first
+func canvasStart(room *Room) int {
+	// mutex is already locked by caller function
+	if len(room.StrokeLog) > 0 && room.StrokeLog[0].OpType() == "clear" {
+		return 1
+	}
+	return 0
+}
+
+// canvasMessage builds the full canvas state: the background, the latest
+// keyframe if it is still valid, and only the operations drawn after it
+func canvasMessage(room *Room) Message {
+	// mutex is already locked by caller function
+	start := canvasStart(room)
+
+	data := map[string]interface{}{
+		"background": room.Background,
+	}
+
+	if room.Keyframe != nil && room.KeyframeOps >= start && room.KeyframeOps <= len(room.StrokeLog) {
+		data["keyframe"] = room.Keyframe.ImageData
+		start = room.KeyframeOps
+	}
+
+	ops := make([]DrawOp, len(room.StrokeLog)-start)
+	copy(ops, room.StrokeLog[start:])
+	data["operations"] = ops
+
+	return Message{
+		Type: "canvas",
+		Data: data,
+	}
+}
+
+// storeKeyframe keeps a canvas snapshot uploaded by the drawer as the new
+// checkpoint for syncing clients
+func storeKeyframe(room *Room, data interface{}) {
+	// mutex is already locked by caller function
+	fields, ok := data.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	imageData, ok := fields["imageData"].(string)
+	if !ok || len(imageData) > maxKeyframeSize || !strings.HasPrefix(imageData, "data:image/") {
+		return
+	}
+
+	room.Keyframe = &DrawData{ImageData: imageData}
+	room.KeyframeOps = len(room.StrokeLog)
+}
+
+// requestKeyframe asks the drawer for a fresh snapshot once enough has been
+// drawn since the last one
+func requestKeyframe(room *Room, drawer *Client) {
+	// mutex is already locked by caller function
+	if (len(room.StrokeLog)-room.KeyframeOps)%keyframeInterval != 0 {
+		return
+	}
+
+	sendMessage(drawer, Message{
+		Type: "requestKeyframe",
+		Data: nil,
+	})
+}
+
+// sendCanvas sends everything drawn so far this round, so a late joiner
+// sees the same canvas as everyone else
+func sendCanvas(client *Client) {
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+
+	sendMessage(client, canvasMessage(room))
+}
+
+// broadcastCanvas makes everyone but the sender redraw the whole canvas
+func broadcastCanvas(room *Room, senderID string) {
+	// mutex is already locked by caller function
+	broadcastToOthers(room, senderID, canvasMessage(room))
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+const (
+	defaultDrawerIdleTime = 0  // Off, an idle drawer is only warned and skipped if the owner sets a time
+	minDrawerIdleTime     = 10 // Seconds without drawing before the drawer is warned
+	maxDrawerIdleTime     = 120
+	drawerIdleGrace       = 10 // Seconds between the warning and the skip
+)
+
+// markDrawerActive restarts the drawer's idle clock
+func markDrawerActive(room *Room) {
+	// mutex is already locked by caller function
+	room.LastDrawAt = time.Now()
+	room.IdleWarned = false
+}
+
+// checkDrawerIdle warns a drawer who hasn't drawn anything for a while, and
+// skips their turn if they still don't. It reports whether the turn is over
+func checkDrawerIdle(room *Room) bool {
+	// mutex is already locked by caller function
+	if room.Settings.DrawerIdleTime == 0 {
+		return false
+	}
+
+	drawer, ok := room.Clients[room.GameState.CurrentDrawer]
+	if !ok {
+		return false
+	}
+
+	idle := time.Since(room.LastDrawAt)
+	limit := time.Duration(room.Settings.DrawerIdleTime) * time.Second
+
+	switch {
+	case idle >= limit+drawerIdleGrace*time.Second:
+		log.Printf("💤 Drawer %s was idle, skipping the turn\n", drawer.Username)
+		broadcastChatMessage(room, systemMessage(room, "drawerIdleSkipped", drawer.Username))
+		broadcastEvent(room, "roundSkipped", map[string]interface{}{
+			"reason": "drawerIdle",
+		})
+		return true
+
+	case idle >= limit && !room.IdleWarned:
+		room.IdleWarned = true
+		sendChatMessage(drawer, systemMessage(room, "drawerIdleWarning", drawerIdleGrace))
+		sendEvent(room, drawer, "drawerIdle", map[string]interface{}{
+			"skipIn": drawerIdleGrace,
+		})
+	}
+	return false
+}
@@ -0,0 +1,408 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"mime"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Drawing is what was on the canvas when a turn ended
+type Drawing struct {
+	ID         string    `json:"id"`
+	RoomCode   string    `json:"-"`
+	Word       string    `json:"word"`
+	DrawerID   string    `json:"drawerId"`
+	DrawerName string    `json:"drawerName"`
+	Width      int       `json:"width"`
+	Height     int       `json:"height"`
+	EndedAt    time.Time `json:"endedAt"`
+	Ops        []DrawOp  `json:"-"`
+}
+
+// drawingKey is where a drawing is stored, as ".json" for what it shows
+// and ".svg" and ".png" for the drawing itself
+func drawingKey(roomCode string, id string, format string) string {
+	return "drawings/" + roomCode + "/" + id + format
+}
+
+// saveDrawing keeps what is on the canvas at the end of a turn and returns
+// the link to its SVG, or nothing if the drawer didn't draw anything
+func saveDrawing(room *Room) string {
+	// mutex is already locked by caller function
+	ops := room.StrokeLog[canvasStart(room):]
+	if len(ops) == 0 {
+		return ""
+	}
+
+	drawing := &Drawing{
+		ID:       uuid.New().String(),
+		RoomCode: room.Code,
+		Word:     room.GameState.CurrentWord,
+		DrawerID: room.GameState.CurrentDrawer,
+		Width:    room.GameState.CanvasWidth,
+		Height:   room.GameState.CanvasHeight,
+		EndedAt:  time.Now(),
+		Ops:      append([]DrawOp(nil), ops...),
+	}
+	if drawer, ok := room.Clients[drawing.DrawerID]; ok {
+		drawing.DrawerName = drawer.Username
+	}
+
+	// Rendering takes a moment, the room doesn't wait for it
+	go storeDrawing(drawing)
+	return publicURL + "/rooms/" + drawing.RoomCode + "/drawings/" + drawing.ID + ".svg"
+}
+
+// storeDrawing renders a drawing and stores it, what it shows last so it
+// is only listed once the files are there
+func storeDrawing(drawing *Drawing) {
+	info, err := json.Marshal(drawing)
+	if err != nil {
+		captureError("marshal", err, map[string]interface{}{
+			"drawingId": drawing.ID,
+		})
+		return
+	}
+
+	var picture bytes.Buffer
+	png.Encode(&picture, renderDrawing(drawing, nil))
+
+	files := []struct {
+		format      string
+		data        []byte
+		contentType string
+	}{
+		{".svg", drawingSVG(drawing), svgContentType},
+		{".png", picture.Bytes(), pngContentType},
+		{".json", info, jsonContentType},
+	}
+	for _, file := range files {
+		key := drawingKey(drawing.RoomCode, drawing.ID, file.format)
+		if err := blobs.Put(key, file.data, file.contentType); err != nil {
+			logError("storage", "Failed to store "+key, err)
+			return
+		}
+	}
+}
+
+// deleteDrawingPlayer drops everything a player drew
+func deleteDrawingPlayer(playerID string) error {
+	infos, err := blobs.List("drawings/")
+	if err != nil {
+		return err
+	}
+
+	for _, info := range infos {
+		base, ok := strings.CutSuffix(info.Key, ".json")
+		if !ok {
+			continue
+		}
+
+		drawing, err := loadDrawingInfo(info.Key)
+		if errors.Is(err, errBlobNotFound) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if drawing.DrawerID != playerID {
+			continue
+		}
+
+		for _, format := range []string{".svg", ".png", ".json"} {
+			if err := blobs.Delete(base + format); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func loadDrawingInfo(key string) (Drawing, error) {
+	var drawing Drawing
+	data, err := blobs.Get(key)
+	if err != nil {
+		return drawing, err
+	}
+	return drawing, json.Unmarshal(data, &drawing)
+}
+
+// drawingsHandler lists the finished drawings of a room, oldest first
+func drawingsHandler(c *gin.Context) {
+	code := c.Param("code")
+	list := []Drawing{}
+	if !isBlobName(code) {
+		c.JSON(http.StatusOK, gin.H{
+			"drawings": list,
+		})
+		return
+	}
+
+	infos, err := blobs.List(drawingKey(code, "", ""))
+	if err != nil {
+		logError(c.GetString("requestId"), "Failed to list drawings", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to list drawings",
+		})
+		return
+	}
+
+	for _, info := range infos {
+		if !strings.HasSuffix(info.Key, ".json") {
+			continue
+		}
+
+		drawing, err := loadDrawingInfo(info.Key)
+		if err != nil {
+			// Expired or half written, it'll be gone or complete soon
+			continue
+		}
+		list = append(list, drawing)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].EndedAt.Before(list[j].EndedAt)
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"drawings": list,
+	})
+}
+
+// drawingHandler downloads a finished drawing as "<id>.svg", or rendered
+// to an image as "<id>.png". The file is named after the word
+func drawingHandler(c *gin.Context) {
+	code := c.Param("code")
+	file := c.Param("file")
+	format := path.Ext(file)
+	id := strings.TrimSuffix(file, format)
+	if (format != ".svg" && format != ".png") || !isBlobName(code) || !isBlobName(id) {
+		respondUnknownMedia(c, "drawing")
+		return
+	}
+
+	drawing, err := loadDrawingInfo(drawingKey(code, id, ".json"))
+	var data []byte
+	if err == nil {
+		data, err = blobs.Get(drawingKey(code, id, format))
+	}
+	if errors.Is(err, errBlobNotFound) {
+		respondUnknownMedia(c, "drawing")
+		return
+	} else if err != nil {
+		logError(c.GetString("requestId"), "Failed to load drawing", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to load drawing",
+		})
+		return
+	}
+
+	contentType := svgContentType
+	if format == ".png" {
+		contentType = pngContentType
+	}
+	c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": drawingFileName(drawing.Word) + format,
+	}))
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// drawingFileName turns a word into a file name, keeping only letters and
+// digits with dashes in between words
+func drawingFileName(word string) string {
+	name := strings.Map(func(char rune) rune {
+		switch {
+		case unicode.IsLetter(char) || unicode.IsDigit(char):
+			return unicode.ToLower(char)
+		case char == ' ' || char == '-':
+			return '-'
+		}
+		return -1
+	}, word)
+
+	if name == "" {
+		return "drawing"
+	}
+	return name
+}
+
+// drawingSVG writes a drawing out as SVG. Strokes stay vectors, but a fill
+// depends on the pixels around it, so each filled area is worked out on a
+// raster and placed as an image
+func drawingSVG(drawing *Drawing) []byte {
+	var svg bytes.Buffer
+	fmt.Fprintf(&svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n",
+		drawing.Width, drawing.Height, drawing.Width, drawing.Height)
+	fmt.Fprintf(&svg, "<title>%s</title>\n", html.EscapeString(drawing.Word))
+	fmt.Fprintf(&svg, "<desc>Drawn by %s</desc>\n", html.EscapeString(drawing.DrawerName))
+	svg.WriteString(`<rect width="100%" height="100%" fill="#ffffff"/>` + "\n")
+
+	renderDrawing(drawing, func(op DrawOp, area *image.NRGBA) {
+		switch op := op.(type) {
+		case Stroke:
+			writeSVGStroke(&svg, op)
+		case Fill:
+			if area == nil {
+				return
+			}
+			var buf bytes.Buffer
+			png.Encode(&buf, area)
+			fmt.Fprintf(&svg, `<image x="%d" y="%d" width="%d" height="%d" href="data:image/png;base64,%s"/>`+"\n",
+				area.Rect.Min.X, area.Rect.Min.Y, area.Rect.Dx(), area.Rect.Dy(), base64.StdEncoding.EncodeToString(buf.Bytes()))
+		}
+	})
+
+	svg.WriteString("</svg>\n")
+	return svg.Bytes()
+}
+
+func writeSVGStroke(svg *bytes.Buffer, stroke Stroke) {
+	color := stroke.Color
+	if stroke.Type == "erase" {
+		color = "#ffffff"
+	}
+
+	if len(stroke.Points) == 1 {
+		fmt.Fprintf(svg, `<circle cx="%.1f" cy="%.1f" r="%.1f" fill="%s"/>`+"\n",
+			stroke.Points[0].X, stroke.Points[0].Y, stroke.Size/2, color)
+		return
+	}
+
+	points := make([]string, len(stroke.Points))
+	for i, p := range stroke.Points {
+		points[i] = fmt.Sprintf("%.1f,%.1f", p.X, p.Y)
+	}
+	fmt.Fprintf(svg, `<polyline points="%s" fill="none" stroke="%s" stroke-width="%.1f" stroke-linecap="round" stroke-linejoin="round"/>`+"\n",
+		strings.Join(points, " "), color, stroke.Size)
+}
+
+// renderDrawing paints a drawing onto a white canvas. Each operation is
+// handed to visit as it is painted, fills along with the area they covered
+func renderDrawing(drawing *Drawing, visit func(op DrawOp, area *image.NRGBA)) *image.RGBA {
+	canvas := image.NewRGBA(image.Rect(0, 0, drawing.Width, drawing.Height))
+	paintRect(canvas, canvas.Rect, color.RGBA{255, 255, 255, 255})
+
+	for _, op := range drawing.Ops {
+		var area *image.NRGBA
+		switch op := op.(type) {
+		case Stroke:
+			paintStroke(canvas, op)
+		case Fill:
+			area = floodFill(canvas, op)
+		}
+		if visit != nil {
+			visit(op, area)
+		}
+	}
+	return canvas
+}
+
+func paintRect(canvas *image.RGBA, rect image.Rectangle, c color.RGBA) {
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			canvas.SetRGBA(x, y, c)
+		}
+	}
+}
+
+// paintStroke draws a stroke with a round brush, stamping it every pixel
+// along each segment
+func paintStroke(canvas *image.RGBA, stroke Stroke) {
+	c := parseColor(stroke.Color)
+	if stroke.Type == "erase" {
+		c = color.RGBA{255, 255, 255, 255}
+	}
+	radius := stroke.Size / 2
+
+	previous := stroke.Points[0]
+	stampBrush(canvas, previous, radius, c)
+	for _, p := range stroke.Points[1:] {
+		dx, dy := p.X-previous.X, p.Y-previous.Y
+		steps := int(max(math.Abs(dx), math.Abs(dy)))
+		for i := 1; i <= steps; i++ {
+			t := float64(i) / float64(steps)
+			stampBrush(canvas, Point{X: previous.X + dx*t, Y: previous.Y + dy*t}, radius, c)
+		}
+		stampBrush(canvas, p, radius, c)
+		previous = p
+	}
+}
+
+func stampBrush(canvas *image.RGBA, center Point, radius float64, c color.RGBA) {
+	rect := image.Rect(int(center.X-radius), int(center.Y-radius), int(center.X+radius)+1, int(center.Y+radius)+1).Intersect(canvas.Rect)
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			dx, dy := float64(x)+0.5-center.X, float64(y)+0.5-center.Y
+			if dx*dx+dy*dy <= radius*radius {
+				canvas.SetRGBA(x, y, c)
+			}
+		}
+	}
+}
+
+// floodFill paints the area of one color around the fill's point, and
+// returns that area on its own, or nil if nothing changed
+func floodFill(canvas *image.RGBA, fill Fill) *image.NRGBA {
+	start := image.Pt(int(fill.Point.X), int(fill.Point.Y))
+	if start.X >= canvas.Rect.Max.X {
+		start.X--
+	}
+	if start.Y >= canvas.Rect.Max.Y {
+		start.Y--
+	}
+	if !start.In(canvas.Rect) {
+		return nil
+	}
+
+	c := parseColor(fill.Color)
+	target := canvas.RGBAAt(start.X, start.Y)
+	if target == c {
+		return nil
+	}
+
+	filled := []image.Point{}
+	bounds := image.Rectangle{Min: start, Max: start.Add(image.Pt(1, 1))}
+	queue := []image.Point{start}
+	canvas.SetRGBA(start.X, start.Y, c)
+	for len(queue) > 0 {
+		p := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		filled = append(filled, p)
+		bounds = bounds.Union(image.Rectangle{Min: p, Max: p.Add(image.Pt(1, 1))})
+
+		for _, next := range []image.Point{{p.X + 1, p.Y}, {p.X - 1, p.Y}, {p.X, p.Y + 1}, {p.X, p.Y - 1}} {
+			if next.In(canvas.Rect) && canvas.RGBAAt(next.X, next.Y) == target {
+				canvas.SetRGBA(next.X, next.Y, c)
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	area := image.NewNRGBA(bounds)
+	for _, p := range filled {
+		area.SetNRGBA(p.X, p.Y, color.NRGBA{c.R, c.G, c.B, 255})
+	}
+	return area
+}
+
+// parseColor reads a "#rrggbb" palette color
+func parseColor(hex string) color.RGBA {
+	c := color.RGBA{A: 255}
+	fmt.Sscanf(strings.TrimPrefix(hex, "#"), "%02x%02x%02x", &c.R, &c.G, &c.B)
+	return c
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	errorQueueSize      = 100 // Reports waiting to be sent before dropping
+	errorReportTimeout  = 5 * time.Second
+	writeErrorThreshold = 5 // Failed writes in a row before a client is reported
+)
+
+// ErrorReport is what the error hook receives for every captured error
+type ErrorReport struct {
+	Kind    string                 `json:"kind"` // "panic", "marshal", "writeError" or "error"
+	Message string                 `json:"message"`
+	Context map[string]interface{} `json:"context,omitempty"`
+	Time    time.Time              `json:"time"`
+}
+
+// errorReports queues reports for the hook set in ERROR_WEBHOOK_URL, it is
+// nil when error reporting is off
+var errorReports = startErrorHook(os.Getenv("ERROR_WEBHOOK_URL"))
+
+// startErrorHook starts posting error reports as JSON to url. Any service
+// that takes a webhook, such as a Sentry relay, can sit behind it
+func startErrorHook(url string) chan ErrorReport {
+	if url == "" {
+		return nil
+	}
+
+	reports := make(chan ErrorReport, errorQueueSize)
+	client := &http.Client{Timeout: errorReportTimeout}
+
+	go func() {
+		for report := range reports {
+			body, err := json.Marshal(report)
+			if err != nil {
+				continue
+			}
+
+			resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+			if err != nil {
+				log.Printf("⚠️ Failed to send error report: %v\n", err)
+				continue
+			}
+			resp.Body.Close()
+		}
+	}()
+
+	return reports
+}
+
+// captureError hands an error to the error hook, if there is one. It never
+// blocks, reports are dropped when the hook falls behind
+func captureError(kind string, err interface{}, context map[string]interface{}) {
+	if errorReports == nil {
+		return
+	}
+
+	select {
+	case errorReports <- ErrorReport{
+		Kind:    kind,
+		Message: fmt.Sprint(err),
+		Context: context,
+		Time:    time.Now(),
+	}:
+	default:
+	}
+}
+
+// clientContext describes a client for an error report
+func clientContext(client *Client) map[string]interface{} {
+	return map[string]interface{}{
+		"clientId":     client.ID,
+		"connectionId": client.ConnID,
+		"username":     client.Username,
+	}
+}
+
+// trackWriteError counts failed writes to a client and reports the client
+// once the failures keep coming
+func trackWriteError(client *Client, err error) {
+	if err == nil {
+		client.WriteErrors.Store(0)
+		return
+	}
+
+	if client.WriteErrors.Add(1) == writeErrorThreshold {
+		clientLogf(client, "⚠️ %d writes in a row failed: %v\n", writeErrorThreshold, err)
+		captureError("writeError", err, clientContext(client))
+	}
+}
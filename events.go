@@ -0,0 +1,27 @@
+package main
+
+// eventMessage builds a machine-readable "event" message, sent alongside the
+// system chat line so clients can react without parsing the text
+func eventMessage(room *Room, event string, fields map[string]interface{}) Message {
+	data := map[string]interface{}{
+		"event": event,
+	}
+	for key, value := range fields {
+		data[key] = value
+	}
+
+	return Message{
+		Type: "event",
+		Data: data,
+	}
+}
+
+func broadcastEvent(room *Room, event string, fields map[string]interface{}) {
+	// mutex is already locked by caller function
+	broadcastMessage(room, eventMessage(room, event, fields))
+}
+
+func sendEvent(room *Room, client *Client, event string, fields map[string]interface{}) {
+	// mutex is already locked by caller function
+	sendMessage(client, eventMessage(room, event, fields))
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strings"
+)
+
+const controlVariant = "control"
+
+// Variant is one arm of a game tuning experiment
+type Variant struct {
+	Name         string
+	HintInterval int // Seconds between letters uncovered by timed hints
+}
+
+var variants = map[string]Variant{
+	controlVariant: {Name: controlVariant, HintInterval: 20},
+	"fastHints":    {Name: "fastHints", HintInterval: 12},
+	"slowHints":    {Name: "slowHints", HintInterval: 30},
+}
+
+// experimentVariants are the variants rooms are split between, set as a
+// comma separated EXPERIMENT_VARIANTS. Without it every room plays the
+// control variant and analytics events go out untagged. Players are never
+// told which variant they are in, so it can't sway how they play
+var experimentVariants = parseVariants(os.Getenv("EXPERIMENT_VARIANTS"))
+
+func parseVariants(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if _, ok := variants[name]; ok && !containsWord(names, name) {
+			names = append(names, name)
+		} else if name != "" {
+			log.Printf("⚠️ Ignoring experiment variant %q\n", name)
+		}
+	}
+	return names
+}
+
+// assignVariant puts a room that just opened into a random variant of the
+// running experiment
+func assignVariant(room *Room) {
+	// mutex is already locked by caller function
+	room.Variant = ""
+	if len(experimentVariants) == 0 {
+		return
+	}
+
+	room.Variant = experimentVariants[room.Rand.Intn(len(experimentVariants))]
+	log.Printf("🧪 Room assigned to variant %s\n", room.Variant)
+}
+
+// roomVariant is the tuning the room plays with
+func roomVariant(room *Room) Variant {
+	// mutex is already locked by caller function
+	if variant, ok := variants[room.Variant]; ok {
+		return variant
+	}
+	return variants[controlVariant]
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	registerInterval  = 10 * time.Second // How often instances report to the lobby
+	registrationTTL   = 30 * time.Second // Instances not heard from for this long are dropped
+	registerTimeout   = 5 * time.Second
+	maxRoomsPerReport = 100
+)
+
+// publicURL is the base URL clients reach this instance on, set with
+// PUBLIC_URL. Listings carry it so the lobby can send players to the
+// instance their room lives on
+var publicURL = strings.TrimSuffix(os.Getenv("PUBLIC_URL"), "/")
+
+// Registration is what an instance reports to the lobby about itself
+type Registration struct {
+	Host  string        `json:"host"`
+	Rooms []RoomListing `json:"rooms"`
+}
+
+type registeredInstance struct {
+	rooms []RoomListing
+	seen  time.Time
+}
+
+// Federation is the lobby's view of the other instances, each of which
+// registers its rooms every few seconds. Instances share nothing else, a
+// player simply connects to the host their room is listed under
+type Federation struct {
+	mu        sync.Mutex
+	instances map[string]*registeredInstance
+}
+
+var federation = &Federation{
+	instances: make(map[string]*registeredInstance),
+}
+
+func (f *Federation) Register(registration Registration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.instances[registration.Host] = &registeredInstance{
+		rooms: registration.Rooms,
+		seen:  time.Now(),
+	}
+}
+
+// Listings returns the rooms of every instance that registered recently,
+// dropping the ones that went quiet
+func (f *Federation) Listings() []RoomListing {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	listings := []RoomListing{}
+	for host, instance := range f.instances {
+		if time.Since(instance.seen) > registrationTTL {
+			delete(f.instances, host)
+			continue
+		}
+		listings = append(listings, instance.rooms...)
+	}
+	return listings
+}
+
+// registerHandler takes an instance's report of its rooms
+func registerHandler(c *gin.Context) {
+	var registration Registration
+	if err := c.ShouldBindJSON(&registration); err != nil || registration.Host == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "a host is required",
+		})
+		return
+	}
+	if registration.Host == publicURL {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "an instance can't register with itself",
+		})
+		return
+	}
+	if len(registration.Rooms) > maxRoomsPerReport {
+		registration.Rooms = registration.Rooms[:maxRoomsPerReport]
+	}
+
+	for i := range registration.Rooms {
+		registration.Rooms[i].Host = registration.Host
+	}
+	federation.Register(registration)
+
+	c.JSON(http.StatusOK, gin.H{
+		"registered": true,
+	})
+}
+
+// startRegistration reports this instance's rooms to the lobby at
+// LOBBY_URL, authenticated with FEDERATION_TOKEN, for as long as the
+// server runs
+func startRegistration() {
+	lobbyURL := strings.TrimSuffix(os.Getenv("LOBBY_URL"), "/")
+	if lobbyURL == "" || publicURL == "" || lobbyURL == publicURL {
+		return
+	}
+
+	log.Printf("🌐 Registering with the lobby at %s as %s\n", lobbyURL, publicURL)
+	client := &http.Client{Timeout: registerTimeout}
+	token := os.Getenv("FEDERATION_TOKEN")
+
+	go func() {
+		ticker := time.NewTicker(registerInterval)
+		defer ticker.Stop()
+
+		for ; ; <-ticker.C {
+			if err := register(client, lobbyURL, token); err != nil {
+				log.Printf("⚠️ Failed to register with the lobby: %v\n", err)
+			}
+		}
+	}()
+}
+
+func register(client *http.Client, lobbyURL string, token string) error {
+	room.mu.RLock()
+	listing := roomListing(room)
+	room.mu.RUnlock()
+
+	body, err := json.Marshal(Registration{
+		Host:  publicURL,
+		Rooms: []RoomListing{listing},
+	})
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest(http.MethodPost, lobbyURL+"/lobby/register", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("lobby answered %s", resp.Status)
+	}
+	return nil
+}
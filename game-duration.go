@@ -0,0 +1,40 @@
+package main
+
+import (
+	"log"
+	"time"
+)
+
+const defaultMaxGameMinutes = 180
+
+// maxGameDuration caps how long a room keeps playing without going back to
+// the lobby, set in minutes with MAX_GAME_MINUTES. Games restart on their
+// own once the results are in, so without it a public room could go on
+// forever
+var maxGameDuration = time.Duration(envInt("MAX_GAME_MINUTES", defaultMaxGameMinutes)) * time.Minute
+
+// gameOverdue reports whether the room has played past the cap. It is
+// checked between turns so no drawing gets cut off
+func gameOverdue(room *Room) bool {
+	// mutex is already locked by caller function
+	return !room.PlayingSince.IsZero() && time.Since(room.PlayingSince) >= maxGameDuration
+}
+
+// concludeOverdueGame ends a game that ran past the cap like the owner
+// ending it: everyone gets the results and the room goes back to the lobby
+func concludeOverdueGame(room *Room) {
+	// mutex is already locked by caller function
+	minutes := int(maxGameDuration.Minutes())
+	log.Printf("⏰ Room %s played for %d minutes, ending the game\n", room.Code, minutes)
+	adminFeed.Publish("gameDurationCapped", map[string]interface{}{
+		"roomCode": room.Code,
+		"minutes":  minutes,
+	})
+
+	broadcastChatMessage(room, systemMessage(room, "maxGameDuration", minutes))
+	broadcastEvent(room, "gameEnded", map[string]interface{}{
+		"reason": "maxDuration",
+	})
+	room.PlayingSince = time.Time{}
+	stopGame(room)
+}
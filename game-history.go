@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultHistoryFile = "history.json"
+	maxHistoryGames    = 50 // Most recent games kept per player
+	defaultGamesPage   = 20
+)
+
+// GameRecord is how one finished game went for a player
+type GameRecord struct {
+	GameID    string    `json:"gameId"`
+	RoomCode  string    `json:"roomCode"`
+	EndedAt   time.Time `json:"endedAt"`
+	Placement int       `json:"placement"`
+	Players   int       `json:"players"` // Who the placement is out of
+	Score     int       `json:"score"`
+	ReplayURL string    `json:"replayUrl,omitempty"` // Until the replay expires from storage
+}
+
+// GameHistory keeps every player's recent games, most recent first, saved
+// to a JSON file so player profiles survive restarts. The file is written
+// without holding mu, writeMu keeps an older snapshot from landing last
+type GameHistory struct {
+	mu      sync.Mutex
+	path    string
+	Players map[string][]GameRecord `json:"players"`
+
+	version int // Of the last snapshot taken
+	writeMu sync.Mutex
+	written int // Version of the snapshot on disk
+}
+
+// gameHistory is saved to HISTORY_FILE, history.json by default
+var gameHistory = loadGameHistory(historyFile())
+
+func historyFile() string {
+	if path := os.Getenv("HISTORY_FILE"); path != "" {
+		return path
+	}
+	return defaultHistoryFile
+}
+
+func loadGameHistory(path string) *GameHistory {
+	history := &GameHistory{path: path}
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if err := json.Unmarshal(data, history); err != nil {
+			log.Printf("⚠️ Ignoring unreadable game history %s\n", path)
+		}
+	}
+	if history.Players == nil {
+		history.Players = make(map[string][]GameRecord)
+	}
+	return history
+}
+
+// Record adds a finished game to the history of the given players, keeping
+// the most recent maxHistoryGames of each
+func (h *GameHistory) Record(results []Player, record GameRecord) error {
+	h.mu.Lock()
+	for _, result := range results {
+		entry := record
+		entry.Placement = result.Rank
+		entry.Score = result.Score
+		games := append([]GameRecord{entry}, h.Players[result.ID]...)
+		h.Players[result.ID] = games[:min(len(games), maxHistoryGames)]
+	}
+	data, version, err := h.snapshot()
+	h.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	return h.write(data, version)
+}
+
+// Games returns up to limit of a player's most recent games
+func (h *GameHistory) Games(playerID string, limit int) []GameRecord {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	games := h.Players[playerID]
+	return append([]GameRecord{}, games[:min(len(games), limit)]...)
+}
+
+// DeletePlayer forgets a player's games
+func (h *GameHistory) DeletePlayer(playerID string) error {
+	h.mu.Lock()
+	if _, ok := h.Players[playerID]; !ok {
+		h.mu.Unlock()
+		return nil
+	}
+	delete(h.Players, playerID)
+	data, version, err := h.snapshot()
+	h.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	return h.write(data, version)
+}
+
+// snapshot marshals the history for write
+func (h *GameHistory) snapshot() ([]byte, int, error) {
+	// mutex is already locked by caller function
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return nil, 0, err
+	}
+	h.version++
+	return data, h.version, nil
+}
+
+// write saves a snapshot, unless a newer one was saved already
+func (h *GameHistory) write(data []byte, version int) error {
+	h.writeMu.Lock()
+	defer h.writeMu.Unlock()
+
+	if version <= h.written {
+		return nil
+	}
+
+	tmp := h.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, h.path); err != nil {
+		return err
+	}
+	h.written = version
+	return nil
+}
+
+// recordGameHistory adds a finished game to its players' histories, with a
+// link to the replay if the game was recorded. Only players who joined with
+// a guest token get one, anyone else has an ID they will never use again
+func recordGameHistory(room *Room, results []Player, replay *Replay) {
+	// mutex is already locked by caller function
+	record := GameRecord{
+		GameID:   uuid.New().String(),
+		RoomCode: room.Code,
+		EndedAt:  time.Now(),
+	}
+	if replay != nil {
+		record.GameID = replay.ID
+		record.ReplayURL = publicURL + replayPath(replay)
+	}
+
+	guests := []Player{}
+	for _, result := range results {
+		// The Twitch audience has no profile
+		if result.Type == "audience" {
+			continue
+		}
+		record.Players++
+
+		if client, ok := room.Clients[result.ID]; ok && client.Guest {
+			guests = append(guests, result)
+		}
+	}
+	if len(guests) == 0 {
+		return
+	}
+
+	// Saving takes a moment, the room doesn't wait for it
+	go func() {
+		if err := gameHistory.Record(guests, record); err != nil {
+			logError("history", "Failed to save game history", err)
+		}
+	}()
+}
+
+// playerGamesHandler lists a player's recent games for their profile, up
+// to "limit" of them
+func playerGamesHandler(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultGamesPage)))
+	if err != nil || limit < 1 || limit > maxHistoryGames {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "limit must be between 1 and " + strconv.Itoa(maxHistoryGames),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"games": gameHistory.Games(c.Param("id"), limit),
+	})
+}
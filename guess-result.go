@@ -0,0 +1,71 @@
+package main
+
+const (
+	GuessWrong          = "wrong"
+	GuessClose          = "close"
+	GuessCorrect        = "correct"
+	GuessAlreadyGuessed = "alreadyGuessed"
+
+	minCloseWordLength = 4 // Shorter words are too easy to be one letter off
+)
+
+// sendGuessResult tells a guesser privately how their guess went
+func sendGuessResult(client *Client, guess string, result string) {
+	sendMessage(client, Message{
+		Type: "guessResult",
+		Data: map[string]interface{}{
+			"guess":  guess,
+			"result": result,
+		},
+	})
+}
+
+// wrongGuessResult grades a wrong guess as close when it is one edit away
+// from the word
+func wrongGuessResult(room *Room, guess string) string {
+	// mutex is already locked by caller function
+	language := room.Settings.Language
+	word := []rune(normalizeGuess(language, room.GameState.CurrentWord))
+	if len(word) < minCloseWordLength {
+		return GuessWrong
+	}
+
+	if editDistance([]rune(normalizeGuess(language, guess)), word) == 1 {
+		return GuessClose
+	}
+	return GuessWrong
+}
+
+// editDistance returns the Levenshtein distance between two words
+func editDistance(a []rune, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+// sendToGuessed writes a chat message only to those who know the word: the
+// drawer, spectators and players who guessed it
+func sendToGuessed(room *Room, chatMsg ChatMessage) {
+	// mutex is already locked by caller function
+	for _, c := range room.Clients {
+		if c.ID == room.GameState.CurrentDrawer || c.Type == "spectator" || room.GameState.PlayersGuessed[c.ID] {
+			sendChatMessage(c, chatMsg)
+		}
+	}
+}
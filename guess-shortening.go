@@ -0,0 +1,52 @@
+package main
+
+import "time"
+
+const (
+	defaultShortenTo = 0 // Off, rooms keep their full round time unless the owner sets one
+	minShortenTo     = 5
+	maxShortenTo     = 60
+)
+
+// shortenRound brings the end of the round closer as more players guess the
+// word. The time left shrinks evenly from the full round down to ShortenTo
+// once half the guessers have it, and is never added back
+func shortenRound(room *Room) {
+	// mutex is already locked by caller function
+	if room.Settings.ShortenTo == 0 {
+		return
+	}
+
+	guessers, guessed := 0, 0
+	for _, c := range room.Clients {
+		if canGuess(room, c.ID) {
+			guessers++
+			if room.GameState.PlayersGuessed[c.ID] {
+				guessed++
+			}
+		}
+	}
+	if guessers == 0 || guessed == guessers {
+		return
+	}
+
+	roundTime := room.GameState.RoundTime
+	limit := room.Settings.ShortenTo
+	if guessed*2 < guessers {
+		limit = roundTime - (roundTime-limit)*guessed*2/guessers
+	}
+
+	remaining := roundRemaining(room)
+	if remaining <= limit {
+		return
+	}
+
+	moveDeadline(room, time.Duration(limit)*time.Second-time.Until(room.RoundDeadline))
+	room.GameState.TimeRemaining = limit
+
+	broadcastChatMessage(room, systemMessage(room, "roundShortened", limit))
+	broadcastEvent(room, "roundShortened", map[string]interface{}{
+		"remaining": limit,
+		"endsAt":    room.GameState.RoundEndsAt,
+	})
+}
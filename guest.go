@@ -0,0 +1,66 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// guestSecret signs guest tokens, set with GUEST_SECRET. Without it a
+// random one is made at startup and guest tokens only last until a restart
+var guestSecret = loadGuestSecret()
+
+func loadGuestSecret() []byte {
+	if secret := os.Getenv("GUEST_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+
+	log.Println("⚠️ GUEST_SECRET is not set, guest identities won't survive a restart")
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	return secret
+}
+
+// issueGuestToken signs a player ID into a token the client keeps, so it
+// plays under the same ID next time without needing an account
+func issueGuestToken(playerID string) string {
+	return playerID + "." + guestSignature(playerID)
+}
+
+// verifyGuestToken returns the player ID a guest token was issued for, if
+// its signature holds
+func verifyGuestToken(token string) (string, bool) {
+	playerID, signature, ok := strings.Cut(token, ".")
+	if !ok || uuid.Validate(playerID) != nil {
+		return "", false
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(guestSignature(playerID))) {
+		return "", false
+	}
+	return playerID, true
+}
+
+func guestSignature(playerID string) string {
+	mac := hmac.New(sha256.New, guestSecret)
+	mac.Write([]byte(playerID))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// departedGuest returns the reconnect token of a guest's session that is
+// still within its grace period, so a new tab can pick it back up
+func departedGuest(room *Room, guestID string) string {
+	// mutex is already locked by caller function
+	for token, departed := range room.Departed {
+		if departed.ID == guestID {
+			return token
+		}
+	}
+	return ""
+}
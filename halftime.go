@@ -0,0 +1,59 @@
+package main
+
+const podiumSize = 3
+
+// Halftime sums up the first half of a game for the halftime message
+type Halftime struct {
+	Round         int             `json:"round"` // Rounds played so far
+	TotalRounds   int             `json:"totalRounds"`
+	Podium        []Player        `json:"podium"`
+	BiggestStreak *HalftimeRecord `json:"biggestStreak,omitempty"`
+	FastestGuess  *HalftimeRecord `json:"fastestGuess,omitempty"`
+}
+
+// HalftimeRecord is the player holding one of the game's records so far
+type HalftimeRecord struct {
+	PlayerID string `json:"playerId"`
+	Username string `json:"username"`
+	Value    int64  `json:"value"` // Words in a row, or milliseconds for the fastest guess
+}
+
+// broadcastHalftime sends the standings once half the rounds are played:
+// the podium, the longest streak and the fastest guess of the game so far
+func broadcastHalftime(room *Room, round int) {
+	// mutex is already locked by caller function
+	results := standings(room)
+	if len(results) == 0 {
+		return
+	}
+
+	halftime := Halftime{
+		Round:       round,
+		TotalRounds: totalRounds(room),
+		Podium:      results[:min(len(results), podiumSize)],
+	}
+	for _, c := range room.Clients {
+		if c.Type == "spectator" {
+			continue
+		}
+		if streak := int64(c.Stats.BestStreak); streak > 0 && (halftime.BiggestStreak == nil || streak > halftime.BiggestStreak.Value) {
+			halftime.BiggestStreak = &HalftimeRecord{PlayerID: c.ID, Username: c.Username, Value: streak}
+		}
+		if fastest := c.Stats.FastestGuess; fastest > 0 && (halftime.FastestGuess == nil || fastest < halftime.FastestGuess.Value) {
+			halftime.FastestGuess = &HalftimeRecord{PlayerID: c.ID, Username: c.Username, Value: fastest}
+		}
+	}
+
+	broadcastChatMessage(room, systemMessage(room, "halftime", results[0].Username, results[0].Score))
+	if record := halftime.BiggestStreak; record != nil {
+		broadcastChatMessage(room, systemMessage(room, "halftimeStreak", record.Username, record.Value))
+	}
+	if record := halftime.FastestGuess; record != nil {
+		broadcastChatMessage(room, systemMessage(room, "halftimeFastest", record.Username, float64(record.Value)/1000))
+	}
+
+	broadcastMessage(room, Message{
+		Type: "halftime",
+		Data: halftime,
+	})
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const pingInterval = 5 * time.Second
+
+// startPinger pings the client periodically with the send time as payload
+// and records the round-trip time when the pong comes back. The returned
+// channel stops the pinger when closed
+func startPinger(client *Client) chan struct{} {
+	// Pinned, in case the client moves to a new connection later
+	conn := client.Conn
+
+	conn.SetPongHandler(func(appData string) error {
+		sent, err := strconv.ParseInt(appData, 10, 64)
+		if err != nil {
+			return nil
+		}
+
+		room.mu.Lock()
+		client.Latency = time.Since(time.Unix(0, sent))
+		room.mu.Unlock()
+		return nil
+	})
+
+	done := make(chan struct{})
+	room.Routines.Start("pinger")
+	go func() {
+		defer room.Routines.Done("pinger")
+		ticker := time.NewTicker(pingInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				// WriteControl is safe to call alongside the other writers
+				payload := []byte(strconv.FormatInt(time.Now().UnixNano(), 10))
+				if err := conn.WriteControl(websocket.PingMessage, payload, time.Now().Add(pingInterval)); err != nil {
+					return
+				}
+			}
+		}
+	}()
+
+	return done
+}
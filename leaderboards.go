@@ -0,0 +1,398 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	PeriodDaily   = "daily"
+	PeriodWeekly  = "weekly"
+	PeriodMonthly = "monthly"
+
+	defaultLeaderboardsFile = "leaderboards.json"
+	seasonWinners           = 3   // Top players kept once a season is over
+	maxPastSeasons          = 100 // Finished seasons kept per period
+	defaultLeaderboardSize  = 25
+	maxLeaderboardSize      = 100
+)
+
+var leaderboardPeriods = []string{PeriodDaily, PeriodWeekly, PeriodMonthly}
+
+// Season is one period of a leaderboard. Periods start over at midnight
+// UTC: every day, every Monday, and on the first of the month
+type Season struct {
+	Period   string    `json:"period"`
+	Name     string    `json:"name"` // Like "2026-10-15", "2026-W42" or "2026-10"
+	StartsAt time.Time `json:"startsAt"`
+	EndsAt   time.Time `json:"endsAt"`
+
+	Players map[string]*SeasonPlayer `json:"players,omitempty"` // By player ID, while the season runs
+	Winners []SeasonPlayer           `json:"winners,omitempty"` // Once the season is over
+}
+
+// SeasonPlayer is a player's tally for a season. Wins are games they
+// finished first in
+type SeasonPlayer struct {
+	PlayerID string `json:"playerId"`
+	Username string `json:"username"`
+	Points   int    `json:"points"`
+	Games    int    `json:"games"`
+	Wins     int    `json:"wins"`
+}
+
+// Leaderboards add up the results of every game into the running season of
+// each period, and keep the winners of past seasons. Everything is saved to
+// a JSON file so it survives restarts. The file is written without holding
+// mu, writeMu keeps an older snapshot from landing last
+type Leaderboards struct {
+	mu      sync.Mutex
+	path    string
+	Current map[string]*Season  `json:"current"` // By period
+	Past    map[string][]Season `json:"past"`    // By period, the most recent first
+
+	version int // Of the last snapshot taken
+	writeMu sync.Mutex
+	written int // Version of the snapshot on disk
+}
+
+// leaderboards are saved to LEADERBOARDS_FILE, leaderboards.json by default
+var leaderboards = loadLeaderboards(leaderboardsFile())
+
+func leaderboardsFile() string {
+	if path := os.Getenv("LEADERBOARDS_FILE"); path != "" {
+		return path
+	}
+	return defaultLeaderboardsFile
+}
+
+func loadLeaderboards(path string) *Leaderboards {
+	boards := &Leaderboards{path: path}
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if err := json.Unmarshal(data, boards); err != nil {
+			log.Printf("⚠️ Ignoring unreadable leaderboards %s\n", path)
+		}
+	}
+	if boards.Current == nil {
+		boards.Current = make(map[string]*Season)
+	}
+	if boards.Past == nil {
+		boards.Past = make(map[string][]Season)
+	}
+
+	go boards.rollLoop()
+	return boards
+}
+
+// rollLoop closes finished seasons as soon as they end, so their winners
+// are settled even when nobody is playing
+func (b *Leaderboards) rollLoop() {
+	for {
+		now := time.Now().UTC()
+		midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+		time.Sleep(time.Until(midnight))
+
+		b.mu.Lock()
+		if !b.roll(time.Now()) {
+			b.mu.Unlock()
+			continue
+		}
+		data, version, err := b.snapshot()
+		b.mu.Unlock()
+
+		if err == nil {
+			err = b.write(data, version)
+		}
+		if err != nil {
+			logError("leaderboards", "Failed to save leaderboards", err)
+		}
+	}
+}
+
+// Record adds a finished game's results to the running seasons
+func (b *Leaderboards) Record(results []Player) error {
+	b.mu.Lock()
+	b.roll(time.Now())
+	for _, season := range b.Current {
+		if season.Players == nil {
+			season.Players = make(map[string]*SeasonPlayer)
+		}
+		for _, result := range results {
+			player, ok := season.Players[result.ID]
+			if !ok {
+				player = &SeasonPlayer{PlayerID: result.ID}
+				season.Players[result.ID] = player
+			}
+			player.Username = result.Username
+			player.Points += result.Score
+			player.Games++
+			if result.Rank == 1 && result.Score > 0 {
+				player.Wins++
+			}
+		}
+	}
+	data, version, err := b.snapshot()
+	b.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	return b.write(data, version)
+}
+
+// Standings returns the running season of a period with its top players
+func (b *Leaderboards) Standings(period string, limit int) (Season, []SeasonPlayer) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.roll(time.Now())
+	season := *b.Current[period]
+	season.Players = nil
+	ranked := rankSeason(b.Current[period])
+	return season, ranked[:min(len(ranked), limit)]
+}
+
+// Rating is how strong a player has been this month, as their average
+// points per game. Players without a game this month rate 0
+func (b *Leaderboards) Rating(playerID string) float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.roll(time.Now())
+	player, ok := b.Current[PeriodMonthly].Players[playerID]
+	if !ok || player.Games == 0 {
+		return 0
+	}
+	return float64(player.Points) / float64(player.Games)
+}
+
+// PastSeasons returns the finished seasons of a period, the most recent
+// first, with their winners
+func (b *Leaderboards) PastSeasons(period string) []Season {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.roll(time.Now())
+	return append([]Season{}, b.Past[period]...)
+}
+
+// roll starts a new season for every period whose season is over, and
+// reports whether any did
+func (b *Leaderboards) roll(now time.Time) bool {
+	// mutex is already locked by caller function
+	rolled := false
+	for _, period := range leaderboardPeriods {
+		name, startsAt, endsAt := seasonBounds(period, now)
+		current := b.Current[period]
+		if current != nil && current.Name == name {
+			continue
+		}
+
+		// Seasons nobody played in aren't worth keeping
+		if current != nil && len(current.Players) > 0 {
+			ranked := rankSeason(current)
+			current.Winners = ranked[:min(len(ranked), seasonWinners)]
+			current.Players = nil
+			b.Past[period] = append([]Season{*current}, b.Past[period]...)
+			b.Past[period] = b.Past[period][:min(len(b.Past[period]), maxPastSeasons)]
+			log.Printf("🏆 %s season %s won by %s\n", period, current.Name, current.Winners[0].Username)
+		}
+
+		b.Current[period] = &Season{
+			Period:   period,
+			Name:     name,
+			StartsAt: startsAt,
+			EndsAt:   endsAt,
+			Players:  make(map[string]*SeasonPlayer),
+		}
+		rolled = true
+	}
+	return rolled
+}
+
+// DeletePlayer takes a player off the running seasons and out of the
+// winners of past ones
+func (b *Leaderboards) DeletePlayer(playerID string) error {
+	b.mu.Lock()
+
+	for _, season := range b.Current {
+		delete(season.Players, playerID)
+	}
+	for period, seasons := range b.Past {
+		for i := range seasons {
+			winners := []SeasonPlayer{}
+			for _, winner := range seasons[i].Winners {
+				if winner.PlayerID != playerID {
+					winners = append(winners, winner)
+				}
+			}
+			b.Past[period][i].Winners = winners
+		}
+	}
+	data, version, err := b.snapshot()
+	b.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	return b.write(data, version)
+}
+
+// snapshot marshals the leaderboards for write
+func (b *Leaderboards) snapshot() ([]byte, int, error) {
+	// mutex is already locked by caller function
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return nil, 0, err
+	}
+	b.version++
+	return data, b.version, nil
+}
+
+// write saves a snapshot, unless a newer one was saved already
+func (b *Leaderboards) write(data []byte, version int) error {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	if version <= b.written {
+		return nil
+	}
+
+	tmp := b.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, b.path); err != nil {
+		return err
+	}
+	b.written = version
+	return nil
+}
+
+// seasonBounds names the season of a period that a moment falls in, and
+// when it starts and ends
+func seasonBounds(period string, now time.Time) (string, time.Time, time.Time) {
+	now = now.UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch period {
+	case PeriodWeekly:
+		// Weeks start on Monday, as ISO weeks do
+		start := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week), start, start.AddDate(0, 0, 7)
+	case PeriodMonthly:
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start.Format("2006-01"), start, start.AddDate(0, 1, 0)
+	}
+	return day.Format(time.DateOnly), day, day.AddDate(0, 0, 1)
+}
+
+// rankSeason orders a season's players by points, then wins
+func rankSeason(season *Season) []SeasonPlayer {
+	ranked := []SeasonPlayer{}
+	for _, player := range season.Players {
+		ranked = append(ranked, *player)
+	}
+
+	sort.Slice(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.Points != b.Points {
+			return a.Points > b.Points
+		}
+		if a.Wins != b.Wins {
+			return a.Wins > b.Wins
+		}
+		return a.PlayerID < b.PlayerID
+	})
+	return ranked
+}
+
+func isLeaderboardPeriod(period string) bool {
+	for _, p := range leaderboardPeriods {
+		if p == period {
+			return true
+		}
+	}
+	return false
+}
+
+// recordLeaderboards adds a finished game to the leaderboards. Like the game
+// history, only players who joined with a guest token are ranked, anyone
+// else has an ID they will never use again
+func recordLeaderboards(room *Room, results []Player) {
+	// mutex is already locked by caller function
+	guests := []Player{}
+	for _, result := range results {
+		// The Twitch audience isn't one player to rank
+		if result.Type == "audience" {
+			continue
+		}
+
+		if client, ok := room.Clients[result.ID]; ok && client.Guest {
+			guests = append(guests, result)
+		}
+	}
+	if len(guests) == 0 {
+		return
+	}
+
+	// Saving takes a moment, the room doesn't wait for it
+	go func() {
+		if err := leaderboards.Record(guests); err != nil {
+			logError("leaderboards", "Failed to save leaderboards", err)
+		}
+	}()
+}
+
+// leaderboardHandler returns the running season of a period, with up to
+// "limit" of its top players
+func leaderboardHandler(c *gin.Context) {
+	period := c.Param("period")
+	if !isLeaderboardPeriod(period) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "unknown period",
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultLeaderboardSize)))
+	if err != nil || limit < 1 || limit > maxLeaderboardSize {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("limit must be between 1 and %d", maxLeaderboardSize),
+		})
+		return
+	}
+
+	season, players := leaderboards.Standings(period, limit)
+	c.JSON(http.StatusOK, gin.H{
+		"season":  season,
+		"players": players,
+	})
+}
+
+// seasonsHandler returns the past seasons of a period with their winners
+func seasonsHandler(c *gin.Context) {
+	period := c.Param("period")
+	if !isLeaderboardPeriod(period) {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "unknown period",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"seasons": leaderboards.PastSeasons(period),
+	})
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RoomListing struct {
+	Code       string `json:"code,omitempty"`
+	Language   string `json:"language"`
+	Region     string `json:"region,omitempty"`
+	Players    int    `json:"players"`
+	Spectators int    `json:"spectators"`
+	IsActive   bool   `json:"isActive"`
+	Host       string `json:"host,omitempty"` // Instance to connect to, when rooms span several
+
+	// Link to watch the room without joining the game, also while it is
+	// in progress
+	SpectateURL string `json:"spectateUrl"`
+}
+
+// roomsHandler lists the rooms players can join here and on the instances
+// registered with this one, optionally only those played in the language
+// given by the "language" query parameter. Rooms in the "region" query
+// parameter, or else the caller's own region, are listed first
+func roomsHandler(c *gin.Context) {
+	room.mu.RLock()
+	listing := roomListing(room)
+	room.mu.RUnlock()
+	listing.Host = publicURL
+
+	rooms := []RoomListing{}
+	for _, listing := range append([]RoomListing{listing}, federation.Listings()...) {
+		if language := c.Query("language"); language == "" || language == listing.Language {
+			listing.SpectateURL = listing.Host + "/ws?spectate=true"
+			rooms = append(rooms, listing)
+		}
+	}
+
+	region := normalizeRegion(c.Query("region"))
+	if region == "" {
+		region = requestRegion(c)
+	}
+	sort.SliceStable(rooms, func(i, j int) bool {
+		return rooms[i].Region == region && rooms[j].Region != region
+	})
+
+	c.JSON(http.StatusOK, gin.H{
+		"rooms":     rooms,
+		"region":    region,
+		"languages": supportedLanguages(),
+	})
+}
+
+// Letters room codes are made of, without ones that are easily mixed up
+const roomCodeLetters = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+const roomCodeLength = 6
+
+// newRoomCode makes the short code a room goes by while it is open
+func newRoomCode(room *Room) string {
+	// mutex is already locked by caller function
+	code := make([]byte, roomCodeLength)
+	for i := range code {
+		code[i] = roomCodeLetters[room.Rand.Intn(len(roomCodeLetters))]
+	}
+	return string(code)
+}
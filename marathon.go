@@ -0,0 +1,18 @@
+package main
+
+const (
+	leaderboardInterval = 5 // Marathon rounds between leaderboard snapshots
+	leaderboardSize     = 10
+)
+
+// broadcastLeaderboard sends a snapshot of the marathon standings every
+// few rounds, since there is no end of the game to wait for
+func broadcastLeaderboard(room *Room, rounds int) {
+	// mutex is already locked by caller function
+	results := standings(room)
+	broadcastChatMessage(room, systemMessage(room, "leaderboard", rounds))
+	broadcastEvent(room, "leaderboard", map[string]interface{}{
+		"rounds":  rounds,
+		"players": results[:min(len(results), leaderboardSize)],
+	})
+}
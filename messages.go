@@ -0,0 +1,211 @@
+package main
+
+import "fmt"
+
+const defaultLanguage = "en"
+
+// System chat strings by language and key. A key missing from a language
+// falls back to English
+var messageCatalog = map[string]map[string]string{
+	"en": {
+		"autoStartCountdown":  "The room is full! The game starts in %d seconds.",
+		"autoStartCancelled":  "The owner cancelled the automatic start.",
+		"ownerSkipped":        "The owner skipped this round.",
+		"youWereKicked":       "You were kicked from the room.",
+		"playerKicked":        "%s was kicked from the room",
+		"tooManyGuesses":      "Too many guesses! Wait %d seconds before guessing again.",
+		"pollResult":          "Spectators say \"%s\" %s (%d of %d votes)",
+		"powerupEarned":       "Streak bonus! You earned a %s power-up.",
+		"powerupUsed":         "%s used a %s power-up!",
+		"playerFroze":         "%s froze %s!",
+		"ownerAgain":          "%s is the room owner again",
+		"drawerDisconnected":  "%s lost connection, waiting for them to come back...",
+		"drawerGone":          "The drawer didn't come back, skipping the turn.",
+		"drawerBack":          "%s is back, the round continues!",
+		"drawerIdleWarning":   "You haven't drawn anything for a while, your turn will be skipped in %d seconds!",
+		"drawerIdleSkipped":   "%s didn't draw anything, skipping their turn.",
+		"wordReveal":          "The word was: %s",
+		"bonusWordMissed":     "Nobody found the bonus word: %s",
+		"playerJoined":        "%s joined the room",
+		"playerReconnected":   "%s reconnected",
+		"playerSpectating":    "%s is now spectating",
+		"playerLeft":          "%s left the room",
+		"noGuessersLeft":      "Nobody is left to guess, skipping the round.",
+		"youAreFrozen":        "You're frozen! Wait a moment before guessing again.",
+		"slowDown":            "Slow down! You can guess again in a moment.",
+		"tiebreakerOnly":      "Only the tied players can guess during the tiebreaker!",
+		"guessedWord":         "%s guessed the word!",
+		"twitchGuessed":       "%s from Twitch chat guessed the word!",
+		"foundBonusWord":      "%s found the bonus word: %s!",
+		"youAreMuted":         "You are muted.",
+		"needPlayers":         "Need at least 2 players to start the game!",
+		"nowDrawing":          "%s is now drawing!",
+		"newTurn":             "Round %d of %d, turn %d of %d! Waiting for drawer to choose a word...",
+		"newMarathonTurn":     "Round %d, turn %d of %d! Waiting for drawer to choose a word...",
+		"leaderboard":         "Standings after %d rounds!",
+		"teamsBalanced":       "Teams are set!",
+		"fixedDrawer":         "%s draws from now on.",
+		"drawOutOfPhase":      "You can only draw during your turn. Keep it up and you will be kicked.",
+		"categoryVoteOpen":    "Vote for the category of the next word!",
+		"categoryChosen":      "The next word is from \"%s\" (%d of %d votes)",
+		"halftime":            "Halftime! %s leads with %d points",
+		"halftimeStreak":      "Longest streak so far: %s with %d in a row",
+		"halftimeFastest":     "Fastest guess so far: %s in %.1f seconds",
+		"targetScoreReached":  "%s reached %d points and wins the game!",
+		"gameEnded":           "The owner ended the game.",
+		"waitingForOwner":     "Waiting for the owner to start the next round.",
+		"maxGameDuration":     "This room has been playing for %d minutes, time for a break! Here are the results.",
+		"finalResults":        "Final Results!",
+		"timesUp":             "Time's up!",
+		"newOwner":            "%s is now the room owner",
+		"roomClosing":         "The room is closing, thanks for playing!",
+		"serverShutdown":      "The server is restarting in %d seconds!",
+		"autoHint":            "Nobody has it yet, here's a hint!",
+		"categoryClue":        "Hint: the word is from the category \"%s\"",
+		"roundShortened":      "Players are catching on, %d seconds left!",
+		"textFlagged":         "%s might be writing the word instead of drawing it",
+		"textBlocked":         "%s's turn was skipped for writing the word",
+		"tiebreakerStart":     "It's a tie! Sudden-death tiebreaker between %s and %s!",
+		"tiebreakerRound":     "Tiebreaker round! Waiting for drawer to choose a word...",
+		"voteKick":            "%s voted to kick %s (%d/%d)",
+		"voteSkip":            "%s voted to skip this round (%d/%d)",
+		"votePassed":          "The vote passed, skipping this round.",
+		"playerReported":      "%s reported %s: %s",
+		"ownerOnlyCommand":    "Only the room owner can use %s",
+		"spectatorCommand":    "Spectators can't use %s",
+		"unknownCommand":      "Unknown command %s",
+		"usage":               "Usage: %s",
+		"noRoundToSkip":       "There is no round to skip",
+		"settingsDuringGame":  "Settings can't be changed during a game",
+		"invalidSetting":      "Invalid value for %s: %s",
+		"playerMuted":         "%s is now muted",
+		"playerUnmuted":       "%s is now unmuted",
+		"reportSent":          "Thanks, your report about %s was sent.",
+		"suggestionReceived":  "Thanks! \"%s\" will be reviewed before it's added.",
+		"suggestionDuplicate": "\"%s\" is already known or waiting for review.",
+		"suggestionsFull":     "Too many words are waiting for review, try again later.",
+		"suggestionTooSoon":   "Wait a moment before suggesting another word.",
+		"suggestionInvalid":   "Suggested words can only contain letters, spaces and hyphens.",
+	},
+	"es": {
+		"autoStartCountdown":  "¡La sala está llena! La partida empieza en %d segundos.",
+		"autoStartCancelled":  "El anfitrión canceló el inicio automático.",
+		"ownerSkipped":        "El anfitrión saltó esta ronda.",
+		"youWereKicked":       "Te han expulsado de la sala.",
+		"playerKicked":        "%s fue expulsado de la sala",
+		"tooManyGuesses":      "¡Demasiados intentos! Espera %d segundos antes de volver a adivinar.",
+		"pollResult":          "Los espectadores dicen \"%s\" %s (%d de %d votos)",
+		"powerupEarned":       "¡Bonus de racha! Ganaste un poder: %s.",
+		"powerupUsed":         "¡%s usó el poder %s!",
+		"playerFroze":         "¡%s congeló a %s!",
+		"ownerAgain":          "%s vuelve a ser el anfitrión",
+		"drawerDisconnected":  "%s perdió la conexión, esperando a que vuelva...",
+		"drawerGone":          "El dibujante no volvió, se salta el turno.",
+		"drawerBack":          "¡%s ha vuelto, la ronda continúa!",
+		"drawerIdleWarning":   "No has dibujado nada en un rato, ¡tu turno se saltará en %d segundos!",
+		"drawerIdleSkipped":   "%s no dibujó nada, se salta su turno.",
+		"wordReveal":          "La palabra era: %s",
+		"bonusWordMissed":     "Nadie encontró la palabra extra: %s",
+		"playerJoined":        "%s entró a la sala",
+		"playerReconnected":   "%s se reconectó",
+		"playerSpectating":    "%s está mirando",
+		"playerLeft":          "%s salió de la sala",
+		"noGuessersLeft":      "No queda nadie para adivinar, se salta la ronda.",
+		"youAreFrozen":        "¡Estás congelado! Espera un momento antes de adivinar.",
+		"slowDown":            "¡Más despacio! Podrás adivinar de nuevo en un momento.",
+		"tiebreakerOnly":      "¡Solo los jugadores empatados pueden adivinar en el desempate!",
+		"guessedWord":         "¡%s adivinó la palabra!",
+		"twitchGuessed":       "¡%s del chat de Twitch adivinó la palabra!",
+		"foundBonusWord":      "¡%s encontró la palabra extra: %s!",
+		"youAreMuted":         "Estás silenciado.",
+		"needPlayers":         "¡Se necesitan al menos 2 jugadores para empezar!",
+		"nowDrawing":          "¡%s está dibujando!",
+		"newTurn":             "¡Ronda %d de %d, turno %d de %d! Esperando a que el dibujante elija una palabra...",
+		"newMarathonTurn":     "¡Ronda %d, turno %d de %d! Esperando a que el dibujante elija una palabra...",
+		"leaderboard":         "¡Clasificación tras %d rondas!",
+		"teamsBalanced":       "¡Los equipos están listos!",
+		"fixedDrawer":         "%s dibuja a partir de ahora.",
+		"drawOutOfPhase":      "Solo puedes dibujar en tu turno. Si sigues, serás expulsado.",
+		"categoryVoteOpen":    "¡Vota la categoría de la siguiente palabra!",
+		"categoryChosen":      "La siguiente palabra es de \"%s\" (%d de %d votos)",
+		"halftime":            "¡Medio tiempo! %s va en cabeza con %d puntos",
+		"halftimeStreak":      "Mejor racha hasta ahora: %s con %d seguidas",
+		"halftimeFastest":     "Acierto más rápido hasta ahora: %s en %.1f segundos",
+		"targetScoreReached":  "¡%s llegó a %d puntos y gana la partida!",
+		"gameEnded":           "El anfitrión terminó la partida.",
+		"waitingForOwner":     "Esperando a que el anfitrión empiece la siguiente ronda.",
+		"maxGameDuration":     "Esta sala lleva %d minutos jugando, ¡hora de un descanso! Aquí están los resultados.",
+		"finalResults":        "¡Resultados finales!",
+		"timesUp":             "¡Se acabó el tiempo!",
+		"newOwner":            "%s es ahora el anfitrión",
+		"roomClosing":         "La sala se está cerrando, ¡gracias por jugar!",
+		"serverShutdown":      "¡El servidor se reiniciará en %d segundos!",
+		"autoHint":            "Nadie lo ha adivinado aún, ¡aquí va una pista!",
+		"categoryClue":        "Pista: la palabra es de la categoría \"%s\"",
+		"roundShortened":      "¡Lo están adivinando, quedan %d segundos!",
+		"textFlagged":         "%s podría estar escribiendo la palabra en vez de dibujarla",
+		"textBlocked":         "Se saltó el turno de %s por escribir la palabra",
+		"tiebreakerStart":     "¡Empate! Desempate a muerte súbita entre %s y %s!",
+		"tiebreakerRound":     "¡Ronda de desempate! Esperando a que el dibujante elija una palabra...",
+		"voteKick":            "%s votó para expulsar a %s (%d/%d)",
+		"voteSkip":            "%s votó para saltar esta ronda (%d/%d)",
+		"votePassed":          "La votación pasó, se salta esta ronda.",
+		"playerReported":      "%s denunció a %s: %s",
+		"ownerOnlyCommand":    "Solo el anfitrión puede usar %s",
+		"spectatorCommand":    "Los espectadores no pueden usar %s",
+		"unknownCommand":      "Comando desconocido %s",
+		"usage":               "Uso: %s",
+		"noRoundToSkip":       "No hay ninguna ronda que saltar",
+		"settingsDuringGame":  "No se pueden cambiar los ajustes durante una partida",
+		"invalidSetting":      "Valor no válido para %s: %s",
+		"playerMuted":         "%s está silenciado",
+		"playerUnmuted":       "%s ya no está silenciado",
+		"reportSent":          "Gracias, tu denuncia sobre %s fue enviada.",
+		"suggestionReceived":  "¡Gracias! \"%s\" se revisará antes de añadirse.",
+		"suggestionDuplicate": "\"%s\" ya existe o está pendiente de revisión.",
+		"suggestionsFull":     "Hay demasiadas palabras pendientes de revisión, inténtalo más tarde.",
+		"suggestionTooSoon":   "Espera un momento antes de sugerir otra palabra.",
+		"suggestionInvalid":   "Las palabras sugeridas solo pueden tener letras, espacios y guiones.",
+	},
+}
+
+// isValidLanguage reports whether a room can be played in the language,
+// which needs a word pack. Languages without a message catalog, such as
+// those only imported as words, get the English messages
+func isValidLanguage(language string) bool {
+	return wordStore.HasLanguage(language)
+}
+
+func supportedLanguages() []string {
+	languages := []string{}
+	for _, language := range wordStore.Languages() {
+		if isValidLanguage(language) {
+			languages = append(languages, language)
+		}
+	}
+	return languages
+}
+
+// translate formats a catalog message in the room's language
+func translate(room *Room, key string, args ...interface{}) string {
+	// mutex is already locked by caller function
+	format, ok := messageCatalog[room.Settings.Language][key]
+	if !ok {
+		format, ok = messageCatalog[defaultLanguage][key]
+	}
+	if !ok {
+		return key
+	}
+
+	return fmt.Sprintf(format, args...)
+}
+
+// systemMessage builds a system chat message from the catalog
+func systemMessage(room *Room, key string, args ...interface{}) ChatMessage {
+	// mutex is already locked by caller function
+	return ChatMessage{
+		Username: "System",
+		Message:  translate(room, key, args...),
+		IsSystem: true,
+	}
+}
@@ -1,7 +1,9 @@
 package main
 
 import (
+	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -10,25 +12,237 @@ import (
 type Client struct {
 	ID       string
 	Username string
+	Avatar   string // From the player's profile
 	Type     string
-	Score    int
+	Token    string // Secret the client reconnects with
 	Conn     *websocket.Conn
+	ConnID   string    // ID of the current connection, tags its log lines
+	JoinedAt time.Time // Breaks ties in the standings, kept across reconnects
+	Guest    bool      // Joined with a signed guest token, so the ID is theirs
+
+	WriteErrors atomic.Int32 // Failed writes in a row
+
+	Stats       PlayerStats // Words guessed and turns drawn this game
+	Streak      int         // Consecutive rounds guessed correctly
+	Powerups    []string    // Earned and not yet used
+	FrozenUntil time.Time   // Guessing is blocked until then
+
+	WrongGuesses  []time.Time // Recent missed guesses, for flood detection
+	CooldownUntil time.Time   // Set when guessing too fast
+
+	OutOfPhaseDraws []time.Time // Recent draw messages sent when not allowed to draw
+	DrawStrikes     int         // Times the client kept drawing out of turn this turn
+
+	Latency time.Duration // Round-trip time of the last ping
+
+	ProfileUpdatedAt time.Time // Last setProfile, to throttle them
+	SuggestedAt      time.Time // Last suggestWord, to throttle them
+
+	Muted  bool // Chat is hidden from others, guesses still count
+	Kicked bool // Removed by the owner, can't reclaim the session
+
+	// Set when the client doesn't have the state the next delta builds on
+	NeedsFullState bool
+
+	// Last yourWord message sent, so it is only resent when it changes
+	PrivateView []byte
 }
 
 type Room struct {
 	Clients        map[string]*Client
 	GameState      *GameState
+	Settings       RoomSettings
+	Scores         *ScoreBoard
 	mu             sync.RWMutex
+	batch          atomic.Pointer[messageBatch]
+	Routines       RoutineCounter // Goroutines the room has running, see diagnosticsHandler
+	Seq            atomic.Uint64  // Number of the last broadcast, see withSeq
 	CurrentDrawer  string
 	RoundStartTime time.Time
+
+	// When the room last started playing from the lobby, games that
+	// restart on their own keep counting from it
+	PlayingSince time.Time
+
+	// When the round runs out, moved by power-ups, pauses and guessing
+	RoundDeadline time.Time
+
+	// Recently disconnected clients by reconnect token
+	Departed map[string]*DepartedClient
+
+	// Set while the round is paused for a disconnected drawer
+	DrawerLeftAt time.Time
+
+	// When the drawer last drew, and whether they were warned for idling
+	LastDrawAt time.Time
+	IdleWarned bool
+
+	// Countdown to start the game once the room is full
+	AutoStartTimer *time.Timer
+
+	// Player votes by target and voter ID, and reports for moderators
+	KickVotes map[string]map[string]bool
+	SkipVotes map[string]bool
+	Reports   []Report
+
+	// Public game state fields last broadcast, deltas are built against it.
+	// spareState is the slice of the broadcast before, reused for the next
+	LastState    []stateField
+	spareState   []stateField
+	StateUpdates int
+
+	// Round of the game, turn within it, the players still to draw this
+	// round, and the order every round is drawn in
+	Round     int
+	Turn      int
+	DrawQueue []string
+	Rotation  []string
+
+	// Players contesting a sudden-death tiebreaker, and how many
+	// tiebreaker turns have been played so far
+	TiebreakerPlayers []string
+	TiebreakerTurns   int
+
+	// Question the spectators are voting on this round, if any
+	Poll *Poll
+
+	// Categories the players are voting on for the next turn during the
+	// intermission, and the one that won
+	CategoryVote *CategoryVote
+	NextCategory string
+
+	// Experiment variant the room was assigned when it opened, if one is
+	// running
+	Variant string
+
+	// Region the room is served in, or its creator came from
+	Region string
+
+	// Player count last published to the admin feed
+	PublishedPlayers int
+
+	// Short code the room is known by while it is open, for links
+	Code string
+
+	// Player the owner picked to draw every turn in classroom mode
+	FixedDrawer string
+
+	// Team of each player by ID, numbered from 1, while teams are on
+	Teams map[string]int
+
+	// Scores of the last finished game, for balancing teams by score
+	LastScores map[string]int
+
+	// Source of every random draw in the room, and the seed it started
+	// from. Each game reseeds it, see seedRoom
+	Rand *rand.Rand
+	Seed int64
+
+	// Last chat messages everyone saw, sent again in a resync
+	RecentChat []ChatMessage
+
+	// Game being recorded for the replay download
+	Replay *Replay
+
+	// Where the owner wants the results of each game sent, if anywhere
+	ResultsWebhook *Webhook
+
+	// Secret handed out when the room is created over REST, authorizes
+	// managing it over HTTP
+	OwnerSecret string
+
+	// Draw operations this round, replayed to late joiners and after
+	// an undo
+	StrokeLog []DrawOp
+
+	// Template under the drawer's strokes this round, clearing the canvas
+	// doesn't remove it
+	Background string
+
+	// Latest canvas snapshot from the drawer and how many logged
+	// operations it already includes
+	Keyframe    *DrawData
+	KeyframeOps int
+}
+
+type RoomSettings struct {
+	BonusWord  bool   `json:"bonusWord"`  // Pick a second secret word each round
+	HintPolicy string `json:"hintPolicy"` // How much of the word guessers see
+
+	// Shared drawing space that strokes are normalized into
+	CanvasWidth  int `json:"canvasWidth"`
+	CanvasHeight int `json:"canvasHeight"`
+
+	Background string `json:"background"` // Locked template drawn under every round
+
+	AutoStartPlayers int `json:"autoStartPlayers"` // Start the game at this many players, 0 to disable
+
+	RoundTime int `json:"roundTime"` // Seconds to guess each word
+
+	Intermission int `json:"intermission"` // Seconds between turns, while the word is revealed
+
+	ManualTurns bool `json:"manualTurns"` // The owner starts each turn once the intermission is over
+
+	AutoHintAt int `json:"autoHintAt"` // Percent of the round after which nobody guessing gets a hint, 0 to disable
+
+	ShortenTo int `json:"shortenTo"` // Seconds left once half the guessers have it, 0 to disable
+
+	DrawerIdleTime int `json:"drawerIdleTime"` // Seconds a drawer may go without drawing, 0 to disable
+
+	HideDrawerTimer bool `json:"hideDrawerTimer"` // The drawer draws without seeing the clock
+
+	CategoryVote bool `json:"categoryVote"` // Players vote on the next word's category between turns
+
+	AnnounceCategory bool `json:"announceCategory"` // Guessers see the word's category as soon as it is chosen
+
+	TextGuard string `json:"textGuard"` // What happens to drawers who seem to write the word out
+
+	TwitchGuessing bool `json:"twitchGuessing"` // The configured Twitch channel's chat guesses along
+
+	Rounds int `json:"rounds"` // Rounds per game, everyone draws once each round
+
+	Mode string `json:"mode"` // Game mode with its own round rules
+
+	Teams       int    `json:"teams"`       // Teams players are split into, 0 to play everyone for themselves
+	TeamBalance string `json:"teamBalance"` // How teams are made up as a game starts
+
+	TargetScore int `json:"targetScore"` // The first to this many points wins right away, 0 to play every round
+
+	Scoring string `json:"scoring"` // How many points a correct guess is worth
+
+	WordCategories string `json:"wordCategories"` // Comma separated categories words come from, empty for all
+
+	KeepScores bool `json:"keepScores"` // Keep scores and progress when too few players are left
+
+	Language string `json:"language"` // Word pack, guess matching and system chat language
+
+	ContainsMatch bool `json:"containsMatch"` // A chat message containing the word counts as a guess
+
+	// Word length limits in letters, 0 for no limit
+	MinWordLength int `json:"minWordLength"`
+	MaxWordLength int `json:"maxWordLength"`
 }
 
 type Player struct {
-	ID        string `json:"id"`
-	Username  string `json:"username"`
-	Type      string `json:"type"`
-	Score     int    `json:"score"`
-	IsDrawing bool   `json:"isDrawing"`
+	ID        string   `json:"id"`
+	Username  string   `json:"username"`
+	Avatar    string   `json:"avatar,omitempty"`
+	Type      string   `json:"type"`
+	Team      int      `json:"team,omitempty"` // Numbered from 1, in team games
+	Score     int      `json:"score"`
+	IsDrawing bool     `json:"isDrawing"`
+	Streak    int      `json:"streak"`
+	Powerups  []string `json:"powerups"`
+	Latency   int      `json:"latency"` // Round-trip time in milliseconds
+
+	PendingTurn bool `json:"pendingTurn"` // Still to draw this round
+
+	Rank int `json:"rank,omitempty"` // Place in the standings, spectators have none
+
+	// Stats for this game
+	WordsGuessed     int   `json:"wordsGuessedThisGame"`
+	AverageGuessTime int64 `json:"averageGuessTime"` // Milliseconds
+	TurnsDrawn       int   `json:"turnsDrawn"`
 }
 
 type Message struct {
@@ -40,19 +254,90 @@ type DrawData struct {
 	ImageData string `json:"imageData"`
 }
 
+// DrawOp is a canvas operation recorded in the stroke log
+type DrawOp interface {
+	OpType() string
+}
+
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+type Stroke struct {
+	Type   string  `json:"type"`
+	Points []Point `json:"points"`
+	Color  string  `json:"color"`
+	Size   float64 `json:"size"`
+	Width  float64 `json:"width,omitempty"`  // Canvas size the points were drawn on
+	Height float64 `json:"height,omitempty"` // Canvas size the points were drawn on
+}
+
+func (s Stroke) OpType() string { return s.Type }
+
+type Fill struct {
+	Type   string  `json:"type"`
+	Point  Point   `json:"point"`
+	Color  string  `json:"color"`
+	Width  float64 `json:"width,omitempty"`  // Canvas size the point was picked on
+	Height float64 `json:"height,omitempty"` // Canvas size the point was picked on
+}
+
+func (f Fill) OpType() string { return f.Type }
+
+type Clear struct {
+	Type string `json:"type"`
+	By   string `json:"by,omitempty"` // The drawer's player ID, or "server" when a new turn wipes the canvas
+}
+
+func (c Clear) OpType() string { return c.Type }
+
 type ChatMessage struct {
 	Username string `json:"username"`
 	Message  string `json:"message"`
 	IsSystem bool   `json:"isSystem"`
+	Channel  string `json:"channel,omitempty"` // "guessed" for chat only players who know the word can see, "discord" for chat relayed from Discord
 }
 
 type GameState struct {
-	IsActive       bool            `json:"isActive"`
-	CurrentWord    string          `json:"-"` // Hidden from clients
-	WordHint       string          `json:"wordHint"`
-	CurrentDrawer  string          `json:"currentDrawer"`
-	TimeRemaining  int             `json:"timeRemaining"`
-	RoundNumber    int             `json:"roundNumber"`
-	WordChoices    []string        `json:"wordChoices,omitempty"`
-	PlayersGuessed map[string]bool `json:"-"`
+	IsActive      bool   `json:"isActive"`
+	Phase         string `json:"phase"` // Lobby, choosing, drawing or intermission
+	CurrentWord   string `json:"-"`     // Hidden from clients
+	WordHint      string `json:"wordHint"`
+	CurrentDrawer string `json:"currentDrawer"`
+	TimeRemaining int    `json:"timeRemaining"`
+	RoundTime     int    `json:"roundTime"`
+	RoundEndsAt   int64  `json:"roundEndsAt,omitempty"` // Server time in unix milliseconds
+
+	IntermissionEndsAt int64           `json:"intermissionEndsAt,omitempty"` // Set between turns, server time in unix milliseconds
+	WaitingForOwner    bool            `json:"waitingForOwner,omitempty"`    // The intermission is over and the owner starts the next turn
+	RoundNumber        int             `json:"roundNumber"`                  // Everyone draws once per round
+	TotalRounds        int             `json:"totalRounds"`
+	TurnNumber         int             `json:"turnNumber"`       // Whose drawing it is within the round
+	TurnsInRound       int             `json:"turnsInRound"`     // 0 during an open-ended tiebreaker
+	UpNext             []string        `json:"upNext,omitempty"` // IDs of the next few drawers
+	WordChoices        []string        `json:"wordChoices,omitempty"`
+	Tiebreaker         bool            `json:"tiebreaker"`
+	HintPolicy         string          `json:"hintPolicy"`
+	Category           string          `json:"category,omitempty"` // Of the word, when the room announces it
+	CanvasWidth        int             `json:"canvasWidth"`
+	CanvasHeight       int             `json:"canvasHeight"`
+	PlayersGuessed     map[string]bool `json:"-"`
+
+	CurrentBonusWord string `json:"-"`                   // Hidden from clients
+	BonusWord        string `json:"bonusWord,omitempty"` // Only sent to the drawer, or once found
+	BonusWordFoundBy string `json:"bonusWordFoundBy,omitempty"`
+
+	PersonalHints map[string]string `json:"-"` // Hints improved by a power-up, per player
+	HintsRevealed int               `json:"-"` // Letters uncovered so far by timed hints
+	GuessTimes    []int64           `json:"-"` // Milliseconds each correct guess took
+	GuessOrder    []string          `json:"-"` // Who made each correct guess
+	AutoHinted    bool              `json:"-"` // A stuck room already got its extra hint
+	TwitchGuesser string            `json:"-"` // Chatter who got the word for Twitch chat
+
+	// What the writing check has seen of the drawer's strokes this turn
+	Strokes          int         `json:"-"`
+	SmallStrokes     []strokeBox `json:"-"` // Most recent letter-sized strokes
+	SmallStrokeCount int         `json:"-"`
+	TextFlagged      bool        `json:"-"`
 }
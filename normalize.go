@@ -0,0 +1,109 @@
+package main
+
+import "strings"
+
+// Normalizer rewrites a guess or word so that spellings players can't
+// easily tell apart, or type on their keyboard, compare equal
+type Normalizer interface {
+	Normalize(text string) string
+}
+
+// NormalizerFunc lets a plain function be used as a Normalizer
+type NormalizerFunc func(text string) string
+
+func (f NormalizerFunc) Normalize(text string) string { return f(text) }
+
+// foldLetters replaces each old letter with its new spelling, given as
+// old, new pairs
+func foldLetters(oldnew ...string) Normalizer {
+	return NormalizerFunc(strings.NewReplacer(oldnew...).Replace)
+}
+
+var (
+	// accentFolding drops the accents of Latin letters, so "cafe" is "café"
+	accentFolding Normalizer = foldLetters(
+		"á", "a", "à", "a", "â", "a", "ä", "a", "ã", "a", "å", "a", "ā", "a", "ą", "a",
+		"é", "e", "è", "e", "ê", "e", "ë", "e", "ē", "e", "ę", "e", "ě", "e",
+		"í", "i", "ì", "i", "î", "i", "ï", "i", "ī", "i",
+		"ó", "o", "ò", "o", "ô", "o", "ö", "o", "õ", "o", "ø", "o", "ō", "o", "ő", "o",
+		"ú", "u", "ù", "u", "û", "u", "ü", "u", "ū", "u", "ů", "u", "ű", "u",
+		"ý", "y", "ÿ", "y", "ñ", "n", "ń", "n", "ň", "n", "ç", "c", "ć", "c", "č", "c",
+		"ś", "s", "š", "s", "ş", "s", "ź", "z", "ż", "z", "ž", "z", "ł", "l", "ř", "r", "ď", "d", "ť", "t",
+		"ß", "ss", "æ", "ae", "œ", "oe",
+	)
+
+	// umlautFolding spells umlauts out the way German does without them,
+	// so "baer" and "bär" are the same guess
+	umlautFolding Normalizer = foldLetters("ä", "ae", "ö", "oe", "ü", "ue")
+
+	// widthFolding turns full-width Latin letters, digits and punctuation,
+	// as CJK keyboards often type them, into their plain forms
+	widthFolding Normalizer = NormalizerFunc(func(text string) string {
+		return strings.Map(func(r rune) rune {
+			if r >= '！' && r <= '～' {
+				return r - '！' + '!'
+			}
+			return r
+		}, text)
+	})
+
+	// kanaFolding writes katakana as hiragana, so a word can be guessed
+	// in either
+	kanaFolding Normalizer = NormalizerFunc(func(text string) string {
+		return strings.Map(func(r rune) rune {
+			if r >= 'ァ' && r <= 'ヶ' {
+				return r - 'ァ' + 'ぁ'
+			}
+			return r
+		}, text)
+	})
+
+	// cyrillicTransliteration writes Cyrillic in Latin letters, so players
+	// without a Cyrillic keyboard can still guess
+	cyrillicTransliteration Normalizer = foldLetters(
+		"а", "a", "б", "b", "в", "v", "г", "g", "д", "d", "е", "e", "ё", "e", "ж", "zh",
+		"з", "z", "и", "i", "й", "i", "к", "k", "л", "l", "м", "m", "н", "n", "о", "o",
+		"п", "p", "р", "r", "с", "s", "т", "t", "у", "u", "ф", "f", "х", "kh", "ц", "ts",
+		"ч", "ch", "ш", "sh", "щ", "shch", "ъ", "", "ы", "y", "ь", "", "э", "e", "ю", "yu", "я", "ya",
+		"є", "ie", "і", "i", "ї", "i", "ґ", "g",
+	)
+
+	// greekTransliteration writes Greek in Latin letters, accents and all
+	greekTransliteration Normalizer = foldLetters(
+		"ά", "a", "έ", "e", "ή", "i", "ί", "i", "ϊ", "i", "ΐ", "i", "ό", "o", "ύ", "y", "ϋ", "y", "ΰ", "y", "ώ", "o",
+		"α", "a", "β", "v", "γ", "g", "δ", "d", "ε", "e", "ζ", "z", "η", "i", "θ", "th",
+		"ι", "i", "κ", "k", "λ", "l", "μ", "m", "ν", "n", "ξ", "x", "ο", "o", "π", "p",
+		"ρ", "r", "σ", "s", "ς", "s", "τ", "t", "υ", "y", "φ", "f", "χ", "ch", "ψ", "ps", "ω", "o",
+	)
+)
+
+// guessNormalizers are applied in order, after lower casing and collapsing
+// spaces, to guesses and words in a language. Languages not listed only
+// get those two
+var guessNormalizers = map[string][]Normalizer{
+	"es": {accentFolding},
+	"fr": {accentFolding},
+	"pt": {accentFolding},
+	"it": {accentFolding},
+	"de": {umlautFolding, accentFolding},
+	"pl": {accentFolding},
+	"cs": {accentFolding},
+	"tr": {accentFolding},
+	"ja": {widthFolding, kanaFolding},
+	"zh": {widthFolding},
+	"ko": {widthFolding},
+	"ru": {cyrillicTransliteration},
+	"uk": {cyrillicTransliteration},
+	"el": {greekTransliteration},
+}
+
+// normalizeGuess puts a guess or word into the form guesses are compared in
+// for the language: lower case, single spaces and the language's own
+// normalizers
+func normalizeGuess(language string, text string) string {
+	text = strings.ToLower(strings.Join(strings.Fields(text), " "))
+	for _, normalizer := range guessNormalizers[language] {
+		text = normalizer.Normalize(text)
+	}
+	return text
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"runtime/debug"
+)
+
+// reportPanic logs a recovered panic with its stack trace and reports it to
+// the admin feed
+func reportPanic(where string, recovered interface{}) {
+	stack := string(debug.Stack())
+	log.Printf("💥 Panic in %s: %v\n%s", where, recovered, stack)
+	adminFeed.Publish("roomError", map[string]interface{}{
+		"where": where,
+		"panic": fmt.Sprint(recovered),
+	})
+	captureError("panic", recovered, map[string]interface{}{
+		"where": where,
+		"stack": stack,
+	})
+}
+
+// recoverRoom is deferred first thing in room goroutines and timers, so a
+// panic in one can't crash the server. Whatever the goroutine was doing
+// will never finish, so the game goes back to the lobby. The goroutine must
+// hold the room lock with a deferred unlock, so it is free again by now
+func recoverRoom(room *Room, where string) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	reportPanic(where, recovered)
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	// Drop whatever the failed transition had queued up
+	room.batch.Store(nil)
+	resetGame(room)
+}
+
+// goRoom runs fn in a new goroutine that recovers from panics, counted in
+// the room's diagnostics under where
+func goRoom(room *Room, where string, fn func()) {
+	room.Routines.Start(where)
+	go func() {
+		defer room.Routines.Done(where)
+		defer recoverRoom(room, where)
+		fn()
+	}()
+}
+
+// handleMessageSafely handles one message from a client. A panic only drops
+// that message, handleMessage has released the room lock by then
+func handleMessageSafely(client *Client, message Message) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			clientLogf(client, "💥 Dropped %q message that caused a panic\n", message.Type)
+			reportPanic("handleMessage", recovered)
+		}
+	}()
+
+	handleMessage(client, message)
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"time"
+)
+
+const (
+	PhaseLobby        = "lobby"        // No game running
+	PhaseChoosing     = "choosing"     // The drawer is picking a word
+	PhaseDrawing      = "drawing"      // The word is being drawn and guessed
+	PhaseIntermission = "intermission" // The word is revealed before the next turn
+
+	maxOutOfPhaseDraws = 30               // Draw messages allowed out of phase inside the window
+	outOfPhaseWindow   = 10 * time.Second // Window they are counted in
+	maxDrawStrikes     = 3                // Times over the limit before the client is kicked
+)
+
+// canDraw reports whether a client may draw right now: only the drawer,
+// and only once their word is chosen and until the turn ends
+func canDraw(room *Room, client *Client) bool {
+	// mutex is already locked by caller function
+	return room.GameState.Phase == PhaseDrawing && client.ID == room.GameState.CurrentDrawer
+}
+
+// resetDrawStrikes gives everyone a clean slate as a turn starts, so
+// strokes in flight at the end of a few turns don't add up to a kick
+func resetDrawStrikes(room *Room) {
+	// mutex is already locked by caller function
+	for _, c := range room.Clients {
+		c.OutOfPhaseDraws = nil
+		c.DrawStrikes = 0
+	}
+}
+
+// recordOutOfPhaseDraw counts a draw message that came in when the client
+// couldn't draw. A few are expected from strokes in flight as a turn ends,
+// clients that keep sending them are warned and then kicked
+func recordOutOfPhaseDraw(room *Room, client *Client) {
+	// mutex is already locked by caller function
+	now := time.Now()
+
+	// Drop messages that fell out of the window
+	recent := client.OutOfPhaseDraws[:0]
+	for _, t := range client.OutOfPhaseDraws {
+		if now.Sub(t) < outOfPhaseWindow {
+			recent = append(recent, t)
+		}
+	}
+	client.OutOfPhaseDraws = append(recent, now)
+
+	if len(client.OutOfPhaseDraws) <= maxOutOfPhaseDraws {
+		return
+	}
+
+	client.OutOfPhaseDraws = nil
+	client.DrawStrikes++
+	clientLogf(client, "🚫 %s keeps drawing out of turn (strike %d)\n", client.Username, client.DrawStrikes)
+	adminFeed.Publish("drawOutOfPhase", map[string]interface{}{
+		"playerId": client.ID,
+		"username": client.Username,
+		"phase":    room.GameState.Phase,
+		"strikes":  client.DrawStrikes,
+	})
+
+	if client.DrawStrikes >= maxDrawStrikes {
+		kickClient(room, client)
+		return
+	}
+
+	sendChatMessage(client, systemMessage(room, "drawOutOfPhase"))
+	sendEvent(room, client, "drawRejected", map[string]interface{}{
+		"phase":   room.GameState.Phase,
+		"strikes": client.DrawStrikes,
+	})
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// PlayerData is everything the server holds about one player
+type PlayerData struct {
+	ID          string         `json:"id"`
+	Username    string         `json:"username"`
+	Type        string         `json:"type"`
+	Connected   bool           `json:"connected"`
+	Score       int            `json:"score"`
+	Streak      int            `json:"streak"`
+	Powerups    []string       `json:"powerups"`
+	Reports     []Report       `json:"reports"`     // Filed by the player
+	Suggestions []Suggestion   `json:"suggestions"` // Words awaiting review
+	Chat        []ChatLogEntry `json:"chat"`        // Still within the chat log's retention
+	Games       []GameRecord   `json:"games"`       // Most recent first
+	Profile     *Profile       `json:"profile"`
+}
+
+// playerSession finds a player's session, connected or within the
+// reconnect grace period, by their player ID or reconnect token
+func playerSession(room *Room, id string, token string) (*Client, *DepartedClient, string) {
+	// mutex is already locked by caller function
+	for _, c := range room.Clients {
+		if (id != "" && c.ID == id) || (token != "" && c.Token == token) {
+			return c, nil, c.Token
+		}
+	}
+
+	purgeDeparted(room)
+	for departedToken, departed := range room.Departed {
+		if (id != "" && departed.ID == id) || (token != "" && departedToken == token) {
+			return nil, departed, departedToken
+		}
+	}
+	return nil, nil, ""
+}
+
+// collectPlayerData gathers a player's data by their ID. Their session, if
+// there still is one, adds what only lives on the connection
+func collectPlayerData(room *Room, id string, client *Client, departed *DepartedClient) PlayerData {
+	// mutex is already locked by caller function
+	data := PlayerData{ID: id, Powerups: []string{}}
+	if client != nil {
+		data.Username = client.Username
+		data.Type = client.Type
+		data.Connected = true
+		data.Streak = client.Streak
+		data.Powerups = client.Powerups
+	} else if departed != nil {
+		data.Username = departed.Username
+		data.Type = departed.Type
+		data.Streak = departed.Streak
+		data.Powerups = departed.Powerups
+	}
+
+	data.Score = room.Scores.Score(data.ID)
+	data.Reports = []Report{}
+	for _, report := range room.Reports {
+		if report.ReporterID == data.ID {
+			data.Reports = append(data.Reports, report)
+		}
+	}
+	data.Suggestions = wordStore.SuggestionsBy(data.ID)
+	data.Chat = chatLog.Recent(data.ID, math.MaxInt)
+	data.Games = gameHistory.Games(data.ID, maxHistoryGames)
+	if profile, ok := profiles.Get(data.ID); ok {
+		data.Profile = &profile
+		if data.Username == "" {
+			data.Username = profile.DisplayName
+		}
+	}
+	return data
+}
+
+// deletePlayerData forgets a player everywhere in the room: their session,
+// if there still is one, is closed for good, their score, votes and the
+// reports they filed are dropped, and the analytics sink is told to purge
+// them. Reports filed against the player are kept for moderation, since they
+// belong to the reporter. The stores that outlive the room are left to
+// deleteStoredPlayerData
+func deletePlayerData(room *Room, id string, client *Client, departed *DepartedClient, token string) {
+	// mutex is already locked by caller function
+	if client != nil {
+		// Kicked clients aren't remembered for a reconnect when the read
+		// loop removes them
+		client.Kicked = true
+		client.Conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseNormalClosure, "data deleted"),
+			time.Now().Add(time.Second))
+		client.Conn.Close()
+	} else if departed != nil {
+		delete(room.Departed, token)
+	}
+
+	room.Scores.Remove(id)
+
+	delete(room.KickVotes, id)
+	for _, voters := range room.KickVotes {
+		delete(voters, id)
+	}
+	delete(room.SkipVotes, id)
+	delete(room.Teams, id)
+	delete(room.LastScores, id)
+
+	if room.Replay != nil {
+		delete(room.Replay.Players, id)
+	}
+
+	reports := []Report{}
+	for _, report := range room.Reports {
+		if report.ReporterID != id {
+			reports = append(reports, report)
+		}
+	}
+	room.Reports = reports
+
+	trackEvent(room, "player_deleted", map[string]interface{}{
+		"playerId": id,
+	})
+	log.Printf("🗑️ Deleted the data of player [%s]\n", id)
+}
+
+// deleteStoredPlayerData removes a player from the stores that outlive the
+// room. Replays and drawings are rewritten blob by blob, so it is called
+// without the room locked
+func deleteStoredPlayerData(id string) error {
+	return errors.Join(wordStore.DeleteSuggestionsBy(id), chatLog.DeletePlayer(id), deleteReplayPlayer(id), deleteDrawingPlayer(id), leaderboards.DeletePlayer(id), gameHistory.DeletePlayer(id), profiles.DeletePlayer(id))
+}
+
+// playerToken is the reconnect token or guest token a player proves who
+// they are with
+func playerToken(c *gin.Context) string {
+	return strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+}
+
+// ownPlayerID resolves the player behind a /me/data token: the session
+// holding it as its reconnect token, or else the guest it was issued to,
+// who may have no session at all
+func ownPlayerID(room *Room, token string) string {
+	// mutex is already locked by caller function
+	client, departed, _ := playerSession(room, "", token)
+	if client != nil {
+		return client.ID
+	}
+	if departed != nil {
+		return departed.ID
+	}
+	if id, ok := verifyGuestToken(token); ok {
+		return id
+	}
+	return ""
+}
+
+func exportOwnDataHandler(c *gin.Context) {
+	exportPlayerData(c, "", playerToken(c))
+}
+
+func deleteOwnDataHandler(c *gin.Context) {
+	removePlayerData(c, "", playerToken(c))
+}
+
+func exportPlayerDataHandler(c *gin.Context) {
+	exportPlayerData(c, c.Param("id"), "")
+}
+
+func deletePlayerDataHandler(c *gin.Context) {
+	removePlayerData(c, c.Param("id"), "")
+}
+
+// exportPlayerData answers with everything held about a player, looked up
+// by their ID in every store whether or not they still have a session.
+// Admins name the player by ID, players by their token
+func exportPlayerData(c *gin.Context, id string, token string) {
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if id == "" {
+		if id = ownPlayerID(room, token); id == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "unauthorized",
+			})
+			return
+		}
+	}
+
+	client, departed, _ := playerSession(room, id, "")
+	c.JSON(http.StatusOK, gin.H{
+		"player": collectPlayerData(room, id, client, departed),
+	})
+}
+
+func removePlayerData(c *gin.Context, id string, token string) {
+	room.mu.Lock()
+	if id == "" {
+		if id = ownPlayerID(room, token); id == "" {
+			room.mu.Unlock()
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error": "unauthorized",
+			})
+			return
+		}
+	}
+
+	client, departed, sessionToken := playerSession(room, id, "")
+	deletePlayerData(room, id, client, departed, sessionToken)
+	room.mu.Unlock()
+
+	if err := deleteStoredPlayerData(id); err != nil {
+		logError(c.GetString("requestId"), "Failed to delete player data", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to delete player data",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deleted": true,
+	})
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"log"
+)
+
+type Poll struct {
+	Question string         `json:"question"`
+	Options  []string       `json:"options"`
+	Votes    map[string]int `json:"-"` // Spectator ID to option index
+}
+
+type PollResult struct {
+	Question string   `json:"question"`
+	Options  []string `json:"options"`
+	Counts   []int    `json:"counts"`
+	Winner   string   `json:"winner"`
+}
+
+var pollQuestions = []Poll{
+	{Question: "What kind of thing is it?", Options: []string{"Animal", "Object", "Place", "Person or character", "Activity"}},
+	{Question: "How big is it?", Options: []string{"Tiny", "Hand-sized", "Person-sized", "Huge"}},
+	{Question: "Where would you find it?", Options: []string{"At home", "Outdoors", "In town", "In stories"}},
+	{Question: "Is it alive?", Options: []string{"Yes", "No", "Sort of"}},
+}
+
+// openPoll asks the spectators a random question about the current word
+func openPoll(room *Room) {
+	// mutex is already locked by caller function
+	if countSpectators(room) == 0 {
+		return
+	}
+
+	question := pollQuestions[room.Rand.Intn(len(pollQuestions))]
+	room.Poll = &Poll{
+		Question: question.Question,
+		Options:  question.Options,
+		Votes:    make(map[string]int),
+	}
+
+	sendToSpectators(room, Message{
+		Type: "poll",
+		Data: room.Poll,
+	})
+}
+
+func votePoll(room *Room, client *Client, data map[string]interface{}) {
+	// mutex is already locked by caller function
+	if room.Poll == nil || client.Type != "spectator" {
+		return
+	}
+
+	option, ok := data["option"].(float64)
+	if !ok || int(option) < 0 || int(option) >= len(room.Poll.Options) {
+		return
+	}
+
+	room.Poll.Votes[client.ID] = int(option)
+
+	// Reveal straight away once every spectator has voted
+	if len(room.Poll.Votes) >= countSpectators(room) {
+		closePoll(room)
+	}
+}
+
+// closePoll reveals the aggregated spectator votes to everyone as a hint
+func closePoll(room *Room) {
+	// mutex is already locked by caller function
+	poll := room.Poll
+	room.Poll = nil
+
+	if poll == nil || len(poll.Votes) == 0 {
+		return
+	}
+
+	counts := make([]int, len(poll.Options))
+	for _, option := range poll.Votes {
+		counts[option]++
+	}
+
+	winner := 0
+	for i, count := range counts {
+		if count > counts[winner] {
+			winner = i
+		}
+	}
+
+	log.Printf("🗳️ Poll closed: %s -> %s\n", poll.Question, poll.Options[winner])
+
+	broadcastMessage(room, Message{
+		Type: "pollResult",
+		Data: PollResult{
+			Question: poll.Question,
+			Options:  poll.Options,
+			Counts:   counts,
+			Winner:   poll.Options[winner],
+		},
+	})
+
+	broadcastChatMessage(room, systemMessage(room, "pollResult", poll.Question, poll.Options[winner], counts[winner], len(poll.Votes)))
+}
+
+func countPlayers(room *Room) int {
+	// mutex is already locked by caller function
+	return len(room.Clients) - countSpectators(room)
+}
+
+func countSpectators(room *Room) int {
+	// mutex is already locked by caller function
+	count := 0
+	for _, c := range room.Clients {
+		if c.Type == "spectator" {
+			count++
+		}
+	}
+	return count
+}
+
+// sendToSpectators writes a message only to spectators, who are allowed
+// to know the word
+func sendToSpectators(room *Room, message Message) {
+	for _, client := range room.Clients {
+		if client.Type == "spectator" {
+			sendMessage(client, message)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"time"
+)
+
+const (
+	PowerupHint   = "hint"   // Reveal one more letter to the user
+	PowerupTime   = "time"   // Add 10 seconds to the round
+	PowerupFreeze = "freeze" // Stop another guesser from guessing for 5 seconds
+)
+
+var powerupTypes = []string{PowerupHint, PowerupTime, PowerupFreeze}
+
+const (
+	powerupStreak  = 3 // Correct guesses in a row needed to earn a power-up
+	maxPowerups    = 3
+	extraTime      = 10 * time.Second
+	freezeDuration = 5 * time.Second
+)
+
+// awardStreak counts a correct guess towards the player's streak and grants
+// a random power-up every few rounds in a row
+func awardStreak(room *Room, client *Client) {
+	// mutex is already locked by caller function
+	client.Streak++
+	client.Stats.BestStreak = max(client.Stats.BestStreak, client.Streak)
+
+	if client.Streak%powerupStreak != 0 || len(client.Powerups) >= maxPowerups {
+		return
+	}
+
+	powerup := powerupTypes[room.Rand.Intn(len(powerupTypes))]
+	client.Powerups = append(client.Powerups, powerup)
+
+	sendChatMessage(client, systemMessage(room, "powerupEarned", powerup))
+	sendEvent(room, client, "powerupEarned", map[string]interface{}{
+		"powerup": powerup,
+		"streak":  client.Streak,
+	})
+}
+
+// resetStreaks breaks the streak of every guesser who missed the word
+func resetStreaks(room *Room) {
+	// mutex is already locked by caller function
+	for _, c := range room.Clients {
+		if canGuess(room, c.ID) && !room.GameState.PlayersGuessed[c.ID] {
+			c.Streak = 0
+		}
+	}
+}
+
+func usePowerup(room *Room, client *Client, data map[string]interface{}) {
+	// mutex is already locked by caller function
+	powerup, _ := data["powerup"].(string)
+
+	// Power-ups only make sense while the word is being drawn and guessed
+	if !room.GameState.IsActive || room.GameState.CurrentWord == "" || !canGuess(room, client.ID) {
+		return
+	}
+
+	index := -1
+	for i, p := range client.Powerups {
+		if p == powerup {
+			index = i
+			break
+		}
+	}
+	if index == -1 {
+		return
+	}
+
+	var targetID string
+	switch powerup {
+	case PowerupHint:
+		if room.GameState.PlayersGuessed[client.ID] {
+			return
+		}
+
+		hint, ok := room.GameState.PersonalHints[client.ID]
+		if !ok {
+			hint = room.GameState.WordHint
+		}
+		if hint == "" {
+			hint = maskWord(room.GameState.CurrentWord)
+		}
+		if room.GameState.PersonalHints == nil {
+			room.GameState.PersonalHints = make(map[string]string)
+		}
+		room.GameState.PersonalHints[client.ID] = revealLetter(room, hint, room.GameState.CurrentWord)
+
+	case PowerupTime:
+		moveDeadline(room, extraTime)
+
+	case PowerupFreeze:
+		targetID, _ = data["targetId"].(string)
+		target, ok := room.Clients[targetID]
+		if !ok || target.ID == client.ID || !canGuess(room, target.ID) || room.GameState.PlayersGuessed[target.ID] {
+			return
+		}
+		target.FrozenUntil = time.Now().Add(freezeDuration)
+
+	default:
+		return
+	}
+
+	client.Powerups = append(client.Powerups[:index], client.Powerups[index+1:]...)
+	clientLogf(client, "✨ %s used power-up %s\n", client.Username, powerup)
+
+	broadcastMessage(room, Message{
+		Type: "powerup",
+		Data: map[string]interface{}{
+			"powerup":  powerup,
+			"playerId": client.ID,
+			"targetId": targetID,
+		},
+	})
+
+	message := systemMessage(room, "powerupUsed", client.Username, powerup)
+	if targetID != "" {
+		message = systemMessage(room, "playerFroze", client.Username, room.Clients[targetID].Username)
+	}
+	broadcastChatMessage(room, message)
+
+	broadcastPlayers(room)
+	broadcastGameState(room)
+}
+
+// isFrozen reports whether a freeze power-up is blocking the client's guesses
+func isFrozen(client *Client) bool {
+	return time.Now().Before(client.FrozenUntil)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Preset is a named bundle of settings a room can start from. Its settings
+// are applied like an updateSettings message, so numbers are float64 as
+// they would be in a decoded JSON body
+type Preset struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Settings    map[string]interface{} `json:"settings"`
+}
+
+// presets are listed in this order. Categories a language doesn't have are
+// skipped, and rooms fall back to the whole pack if none of them are there
+var presets = []Preset{
+	{
+		Name:        "casual",
+		Description: "Relaxed games with generous hints and even scoring",
+		Settings: map[string]interface{}{
+			"rounds":         3.0,
+			"roundTime":      80.0,
+			"hintPolicy":     HintFirstLast,
+			"wordCategories": "",
+			"scoring":        ScoringFlat,
+			"mode":           ModeClassic,
+			"maxWordLength":  0.0,
+			"intermission":   5.0,
+		},
+	},
+	{
+		Name:        "competitive",
+		Description: "Longer games where only the length is shown and fast guesses score more",
+		Settings: map[string]interface{}{
+			"rounds":         5.0,
+			"roundTime":      90.0,
+			"hintPolicy":     HintLengthOnly,
+			"wordCategories": "",
+			"scoring":        ScoringSpeed,
+			"mode":           ModeClassic,
+			"maxWordLength":  0.0,
+			"intermission":   5.0,
+		},
+	},
+	{
+		Name:        "kids",
+		Description: "Short, simple words with plenty of time and hints",
+		Settings: map[string]interface{}{
+			"rounds":         2.0,
+			"roundTime":      120.0,
+			"hintPolicy":     HintFirstLast,
+			"wordCategories": "kids,animals",
+			"scoring":        ScoringFlat,
+			"mode":           ModeClassic,
+			"maxWordLength":  8.0,
+			"intermission":   5.0,
+		},
+	},
+	{
+		Name:        "speed",
+		Description: "30 second rounds with one word to draw, fast hints and double points for quick guesses",
+		Settings: map[string]interface{}{
+			"rounds":         3.0,
+			"roundTime":      30.0,
+			"hintPolicy":     HintTimed,
+			"wordCategories": "",
+			"scoring":        ScoringSpeed,
+			"mode":           ModeSpeed,
+			"maxWordLength":  0.0,
+			"intermission":   3.0,
+		},
+	},
+}
+
+func findPreset(name string) (Preset, bool) {
+	for _, preset := range presets {
+		if strings.EqualFold(preset.Name, name) {
+			return preset, true
+		}
+	}
+	return Preset{}, false
+}
+
+// presetsHandler lists the presets, any of which can be passed as "preset"
+// when creating the room or changing its settings
+func presetsHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"presets": presets,
+	})
+}
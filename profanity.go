@@ -0,0 +1,57 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// blockedWords are matched against whole words, after undoing common
+// letter swaps like "4" for "a". PROFANITY_FILE adds more, one per line
+var blockedWords = loadBlockedWords(os.Getenv("PROFANITY_FILE"))
+
+var defaultBlockedWords = []string{
+	"fuck", "fucker", "fucking", "shit", "bitch", "cunt", "asshole", "dick", "cock", "pussy",
+	"bastard", "slut", "whore", "nigger", "nigga", "faggot", "fag", "retard",
+	"puta", "puto", "mierda", "joder", "coño", "cabron", "cabrón", "pendejo", "maricon", "maricón", "gilipollas",
+}
+
+var leetLetters = strings.NewReplacer("0", "o", "1", "i", "3", "e", "4", "a", "5", "s", "7", "t", "@", "a", "$", "s", "!", "i")
+
+func loadBlockedWords(path string) map[string]bool {
+	words := make(map[string]bool)
+	for _, word := range defaultBlockedWords {
+		words[word] = true
+	}
+	if path == "" {
+		return words
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("⚠️ Ignoring profanity list %s: %v\n", path, err)
+		return words
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if word := strings.ToLower(strings.TrimSpace(line)); word != "" {
+			words[word] = true
+		}
+	}
+	return words
+}
+
+// containsProfanity reports whether any word of a text is on the blocked
+// list, also when spelled with digits or symbols for letters
+func containsProfanity(text string) bool {
+	text = leetLetters.Replace(strings.ToLower(text))
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, word := range words {
+		if blockedWords[word] {
+			return true
+		}
+	}
+	return false
+}
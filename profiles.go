@@ -0,0 +1,308 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultProfilesFile = "profiles.json"
+	minDisplayNameSize  = 2
+	maxDisplayNameSize  = 20
+	maxBioSize          = 160
+	maxAvatarURLSize    = 512
+
+	// Shortest wait between profile changes over the WebSocket
+	profileUpdateInterval = 10 * time.Second
+)
+
+// builtinAvatar names one of the avatars clients ship with
+var builtinAvatar = regexp.MustCompile(`^[a-z0-9-]{1,32}$`)
+
+var (
+	errDisplayName = errors.New("display names are 2 to 20 letters, digits, spaces or simple punctuation")
+	errBio         = errors.New("bios are at most 160 characters")
+	errAvatar      = errors.New("avatars are a built-in avatar name or an https image URL")
+	errProfanity   = errors.New("please keep it friendly")
+	errNotGuest    = errors.New("join with a guest token to keep a profile")
+	errTooSoon     = errors.New("wait a moment before changing your profile again")
+)
+
+// Profile is what a player shows about themselves. The display name and
+// avatar appear in player lists, the bio on their profile page
+type Profile struct {
+	PlayerID    string    `json:"playerId"`
+	DisplayName string    `json:"displayName,omitempty"`
+	Avatar      string    `json:"avatar,omitempty"`
+	Bio         string    `json:"bio,omitempty"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// ProfileStore keeps player profiles by player ID, saved to a JSON file so
+// they survive restarts
+type ProfileStore struct {
+	mu       sync.Mutex
+	path     string
+	Profiles map[string]Profile `json:"profiles"`
+}
+
+// profiles are saved to PROFILES_FILE, profiles.json by default
+var profiles = loadProfiles(profilesFile())
+
+func profilesFile() string {
+	if path := os.Getenv("PROFILES_FILE"); path != "" {
+		return path
+	}
+	return defaultProfilesFile
+}
+
+func loadProfiles(path string) *ProfileStore {
+	store := &ProfileStore{path: path}
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if err := json.Unmarshal(data, store); err != nil {
+			log.Printf("⚠️ Ignoring unreadable profiles %s\n", path)
+		}
+	}
+	if store.Profiles == nil {
+		store.Profiles = make(map[string]Profile)
+	}
+	return store
+}
+
+func (s *ProfileStore) Get(playerID string) (Profile, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	profile, ok := s.Profiles[playerID]
+	return profile, ok
+}
+
+// Set validates a profile and saves it in place of the player's old one
+func (s *ProfileStore) Set(profile Profile) (Profile, error) {
+	profile, err := validateProfile(profile)
+	if err != nil {
+		return profile, err
+	}
+	profile.UpdatedAt = time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Profiles[profile.PlayerID] = profile
+	return profile, s.save()
+}
+
+func (s *ProfileStore) DeletePlayer(playerID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.Profiles[playerID]; !ok {
+		return nil
+	}
+	delete(s.Profiles, playerID)
+	return s.save()
+}
+
+func (s *ProfileStore) save() error {
+	// mutex is already locked by caller function
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// validateProfile tidies up the fields of a profile and checks them. Every
+// field is optional, empty ones are cleared
+func validateProfile(profile Profile) (Profile, error) {
+	profile.DisplayName = strings.Join(strings.Fields(profile.DisplayName), " ")
+	profile.Bio = strings.TrimSpace(profile.Bio)
+	profile.Avatar = strings.TrimSpace(profile.Avatar)
+
+	if name := profile.DisplayName; name != "" {
+		size := utf8.RuneCountInString(name)
+		if size < minDisplayNameSize || size > maxDisplayNameSize || strings.IndexFunc(name, isBadNameRune) >= 0 ||
+			strings.EqualFold(name, "System") {
+			return profile, errDisplayName
+		}
+	}
+
+	if utf8.RuneCountInString(profile.Bio) > maxBioSize || strings.IndexFunc(profile.Bio, isBadBioRune) >= 0 {
+		return profile, errBio
+	}
+
+	if avatar := profile.Avatar; avatar != "" && !builtinAvatar.MatchString(avatar) {
+		link, err := url.Parse(avatar)
+		if err != nil || link.Scheme != "https" || link.Host == "" || link.User != nil || len(avatar) > maxAvatarURLSize {
+			return profile, errAvatar
+		}
+	}
+
+	if containsProfanity(profile.DisplayName) || containsProfanity(profile.Bio) {
+		return profile, errProfanity
+	}
+	return profile, nil
+}
+
+func isBadNameRune(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsMark(r) && !strings.ContainsRune(" _-.'", r)
+}
+
+func isBadBioRune(r rune) bool {
+	return unicode.IsControl(r) && r != '\n'
+}
+
+// applyProfile shows a connecting player with the display name and avatar
+// of their saved profile
+func applyProfile(client *Client) {
+	profile, ok := profiles.Get(client.ID)
+	if !ok {
+		return
+	}
+	if profile.DisplayName != "" {
+		client.Username = profile.DisplayName
+	}
+	client.Avatar = profile.Avatar
+}
+
+// setProfile saves the profile a connected player sent in a setProfile
+// message, and shows its name and avatar to the room right away. Only
+// players who joined with a guest token own their ID, anyone else would be
+// writing a profile for a throwaway ID or someone else's
+func setProfile(room *Room, client *Client, data map[string]interface{}) {
+	// mutex is already locked by caller function
+	if !client.Guest {
+		rejectProfile(room, client, errNotGuest)
+		return
+	}
+	if time.Since(client.ProfileUpdatedAt) < profileUpdateInterval {
+		rejectProfile(room, client, errTooSoon)
+		return
+	}
+	client.ProfileUpdatedAt = time.Now()
+
+	displayName, _ := data["displayName"].(string)
+	avatar, _ := data["avatar"].(string)
+	bio, _ := data["bio"].(string)
+
+	profile, err := profiles.Set(Profile{
+		PlayerID:    client.ID,
+		DisplayName: displayName,
+		Avatar:      avatar,
+		Bio:         bio,
+	})
+	if err != nil {
+		rejectProfile(room, client, err)
+		return
+	}
+
+	if profile.DisplayName != "" && profile.DisplayName != client.Username {
+		clientLogf(client, "🪪 %s is now called %s\n", client.Username, profile.DisplayName)
+		client.Username = profile.DisplayName
+	}
+	client.Avatar = profile.Avatar
+
+	sendEvent(room, client, "profileUpdated", map[string]interface{}{
+		"profile": profile,
+	})
+	broadcastPlayers(room)
+}
+
+func rejectProfile(room *Room, client *Client, err error) {
+	// mutex is already locked by caller function
+	sendEvent(room, client, "profileRejected", map[string]interface{}{
+		"reason": err.Error(),
+	})
+}
+
+// guestAuth only lets requests through that carry a valid guest token as a
+// bearer token, and passes on the player ID it was issued for
+func guestAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		playerID, ok := verifyGuestToken(playerToken(c))
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "unauthorized",
+			})
+			return
+		}
+		c.Set("playerId", playerID)
+		c.Next()
+	}
+}
+
+// profileHandler returns a player's public profile
+func profileHandler(c *gin.Context) {
+	profile, ok := profiles.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "no profile",
+		})
+		return
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+func ownProfileHandler(c *gin.Context) {
+	profile, ok := profiles.Get(c.GetString("playerId"))
+	if !ok {
+		profile = Profile{PlayerID: c.GetString("playerId")}
+	}
+	c.JSON(http.StatusOK, profile)
+}
+
+// updateOwnProfileHandler replaces the profile of the guest token's player,
+// and shows it in the room if they are playing
+func updateOwnProfileHandler(c *gin.Context) {
+	var profile Profile
+	if err := c.ShouldBindJSON(&profile); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid request body",
+		})
+		return
+	}
+	profile.PlayerID = c.GetString("playerId")
+
+	profile, err := profiles.Set(profile)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if client, ok := room.Clients[profile.PlayerID]; ok {
+		if beginBatch(room) {
+			defer flushBatch(room)
+		}
+		if profile.DisplayName != "" {
+			client.Username = profile.DisplayName
+		}
+		client.Avatar = profile.Avatar
+		broadcastPlayers(room)
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
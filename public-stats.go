@@ -0,0 +1,87 @@
+package main
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DailyCounter counts events per UTC day, starting over at midnight
+type DailyCounter struct {
+	mu    sync.Mutex
+	day   string
+	count int
+}
+
+var gamesPlayed = &DailyCounter{}
+
+func (d *DailyCounter) Add() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.roll()
+	d.count++
+}
+
+func (d *DailyCounter) Today() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.roll()
+	return d.count
+}
+
+func (d *DailyCounter) roll() {
+	// mutex is already locked by caller function
+	if today := time.Now().UTC().Format(time.DateOnly); today != d.day {
+		d.day = today
+		d.count = 0
+	}
+}
+
+// MostGuessed returns the word players have guessed most often in a
+// language, and how often
+func (s *WordStore) MostGuessed(language string) (string, int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	best, guessed := "", 0
+	for word, stats := range s.Stats[language] {
+		if stats.Guessed > guessed || (stats.Guessed == guessed && guessed > 0 && word < best) {
+			best, guessed = word, stats.Guessed
+		}
+	}
+	return best, guessed
+}
+
+// statsHandler returns public stats for a homepage ticker, across this
+// instance and the ones registered with it. The most guessed word is for
+// the "language" query parameter, English by default
+func statsHandler(c *gin.Context) {
+	room.mu.RLock()
+	listing := roomListing(room)
+	room.mu.RUnlock()
+
+	players, activeGames := 0, 0
+	for _, listing := range append([]RoomListing{listing}, federation.Listings()...) {
+		players += listing.Players
+		if listing.IsActive {
+			activeGames++
+		}
+	}
+
+	language := c.DefaultQuery("language", defaultLanguage)
+	word, guessed := wordStore.MostGuessed(language)
+
+	c.JSON(http.StatusOK, gin.H{
+		"onlinePlayers":    players,
+		"activeGames":      activeGames,
+		"gamesPlayedToday": gamesPlayed.Today(),
+		"mostGuessedWord": gin.H{
+			"word":    word,
+			"guessed": guessed,
+		},
+	})
+}
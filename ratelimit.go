@@ -0,0 +1,148 @@
+package main
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultRateBurst     = 10 // Connection attempts an IP can make at once
+	defaultRatePerMinute = 30 // Attempts refilled per minute
+	rateLimitIdle        = 10 * time.Minute
+
+	defaultRoomRateBurst     = 3 // Rooms an IP can create at once
+	defaultRoomRatePerMinute = 2
+)
+
+type rateBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter is a token bucket per client IP
+type RateLimiter struct {
+	mu      sync.Mutex
+	burst   float64
+	refill  float64 // Tokens per second
+	buckets map[string]*rateBucket
+}
+
+func NewRateLimiter(burst int, perMinute int) *RateLimiter {
+	limiter := &RateLimiter{
+		burst:   float64(burst),
+		refill:  float64(perMinute) / 60,
+		buckets: make(map[string]*rateBucket),
+	}
+
+	go limiter.cleanup()
+	return limiter
+}
+
+// Allow takes a token from the IP's bucket. When it is empty it reports
+// false and how long until the next token
+func (l *RateLimiter) Allow(ip string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	bucket, ok := l.buckets[ip]
+	if !ok {
+		bucket = &rateBucket{tokens: l.burst, last: now}
+		l.buckets[ip] = bucket
+	}
+
+	bucket.tokens = math.Min(l.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*l.refill)
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / l.refill * float64(time.Second))
+		return false, wait
+	}
+
+	bucket.tokens--
+	return true, 0
+}
+
+// cleanup forgets IPs that have been quiet long enough to be full again,
+// so the buckets of one-off visitors don't pile up
+func (l *RateLimiter) cleanup() {
+	ticker := time.NewTicker(rateLimitIdle)
+	defer ticker.Stop()
+
+	// A slow refill takes longer than rateLimitIdle to fill a bucket, and
+	// forgetting it sooner would hand out a fresh burst
+	idle := max(rateLimitIdle, time.Duration(l.burst/l.refill*float64(time.Second)))
+
+	for range ticker.C {
+		l.mu.Lock()
+		for ip, bucket := range l.buckets {
+			if time.Since(bucket.last) > idle {
+				delete(l.buckets, ip)
+			}
+		}
+		l.mu.Unlock()
+	}
+}
+
+// rateLimit turns away clients that connect too often, configured with
+// WS_RATE_BURST and WS_RATE_PER_MINUTE
+func rateLimit() gin.HandlerFunc {
+	return limitRequests(NewRateLimiter(
+		envInt("WS_RATE_BURST", defaultRateBurst),
+		envInt("WS_RATE_PER_MINUTE", defaultRatePerMinute),
+	), "too many connection attempts")
+}
+
+// roomRateLimit keeps anyone from creating the room over and over to take
+// it over while it's empty, configured with ROOM_RATE_BURST and
+// ROOM_RATE_PER_MINUTE
+func roomRateLimit() gin.HandlerFunc {
+	return limitRequests(NewRateLimiter(
+		envInt("ROOM_RATE_BURST", defaultRoomRateBurst),
+		envInt("ROOM_RATE_PER_MINUTE", defaultRoomRatePerMinute),
+	), "too many rooms created")
+}
+
+func limitRequests(limiter *RateLimiter, reason string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, wait := limiter.Allow(c.ClientIP())
+		if !allowed {
+			log.Printf("🚦 Rate limited %s\n", c.ClientIP())
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error": reason,
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// trustedProxies are the addresses or CIDR ranges in TRUSTED_PROXIES,
+// separated by commas, whose forwarding headers are believed. None by default
+func trustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// envInt reads a positive number from the environment, or the fallback
+func envInt(name string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"sync"
+)
+
+const maxRecentWords = 100 // Words remembered per language
+
+// RecentWords remembers the last words played in each language, across
+// rounds and games, so new word choices can steer clear of them
+type RecentWords struct {
+	mu    sync.Mutex
+	words map[string][]string // Language to words, oldest first
+}
+
+var recentWords = &RecentWords{
+	words: make(map[string][]string),
+}
+
+// Add marks a word as just played, moving it to the back if it was
+// already remembered
+func (r *RecentWords) Add(language string, word string) {
+	if word == "" {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	words := []string{}
+	for _, w := range r.words[language] {
+		if !strings.EqualFold(w, word) {
+			words = append(words, w)
+		}
+	}
+	words = append(words, word)
+
+	if len(words) > maxRecentWords {
+		words = words[len(words)-maxRecentWords:]
+	}
+	r.words[language] = words
+}
+
+// Set returns the recent words of a language for quick lookups
+func (r *RecentWords) Set(language string) map[string]bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	set := make(map[string]bool)
+	for _, word := range r.words[language] {
+		set[strings.ToLower(word)] = true
+	}
+	return set
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// How long a dropped client can come back as themselves
+const reconnectGrace = 30 * time.Second
+
+// DepartedClient is what's kept of a disconnected client while they may
+// still reconnect with their token
+type DepartedClient struct {
+	ID       string
+	Username string
+	Type     string
+	Streak   int
+	Powerups []string
+	Stats    PlayerStats
+	JoinedAt time.Time
+	LeftAt   time.Time
+	Guest    bool
+}
+
+// rememberDeparted keeps a leaving client's identity around for the
+// reconnect grace period
+func rememberDeparted(room *Room, client *Client) {
+	// mutex is already locked by caller function
+	room.Departed[client.Token] = &DepartedClient{
+		ID:       client.ID,
+		Username: client.Username,
+		Type:     client.Type,
+		Streak:   client.Streak,
+		Powerups: client.Powerups,
+		Stats:    client.Stats,
+		JoinedAt: client.JoinedAt,
+		LeftAt:   time.Now(),
+		Guest:    client.Guest,
+	}
+}
+
+// liveSession returns the connected client a reconnect token belongs to
+func liveSession(room *Room, token string) *Client {
+	// mutex is already locked by caller function
+	if token == "" {
+		return nil
+	}
+
+	for _, c := range room.Clients {
+		if c.Token == token {
+			return c
+		}
+	}
+	return nil
+}
+
+// takeOverSession moves a connected client onto a new socket and closes the
+// old one, so a second tab doesn't create a second player
+func takeOverSession(client *Client, conn *websocket.Conn, connID string) {
+	// mutex is already locked by caller function
+	oldConn := client.Conn
+	client.Conn = conn
+	client.ConnID = connID
+
+	oldConn.WriteControl(websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, "session opened elsewhere"),
+		time.Now().Add(time.Second))
+	oldConn.Close()
+
+	clientLogf(client, "🔁 %s [%s] moved to a new connection\n", client.Username, client.ID)
+}
+
+// reclaimSession looks up the departed client a reconnect token belongs to,
+// if they left recently enough
+func reclaimSession(room *Room, token string) (*DepartedClient, bool) {
+	// mutex is already locked by caller function
+	purgeDeparted(room)
+
+	departed, ok := room.Departed[token]
+	if !ok {
+		return nil, false
+	}
+
+	delete(room.Departed, token)
+	return departed, true
+}
+
+// restoreClient gives a reconnecting client back their old identity
+func restoreClient(room *Room, client *Client, departed *DepartedClient) {
+	// mutex is already locked by caller function
+	client.ID = departed.ID
+	client.Streak = departed.Streak
+	client.Powerups = departed.Powerups
+	client.Stats = departed.Stats
+	client.JoinedAt = departed.JoinedAt
+	client.Guest = client.Guest || departed.Guest
+
+	switch departed.Type {
+	case "owner":
+		// Whoever was handed ownership while the owner was away gives it back
+		for _, c := range room.Clients {
+			if c.Type == "owner" {
+				c.Type = "player"
+			}
+		}
+		client.Type = "owner"
+		clientLogf(client, "👑 Owner %s reconnected and regained ownership\n", client.Username)
+
+		broadcastChatMessage(room, systemMessage(room, "ownerAgain", client.Username))
+		broadcastEvent(room, "ownerChanged", map[string]interface{}{
+			"playerId": client.ID,
+			"username": client.Username,
+		})
+
+	case "spectator":
+		client.Type = "spectator"
+	}
+
+	if room.GameState.IsActive && client.ID == room.GameState.CurrentDrawer && !room.DrawerLeftAt.IsZero() {
+		resumeDrawer(room, client)
+	}
+}
+
+// pauseForDrawer holds the round while the drawer is disconnected, and skips
+// their turn if they don't make it back within the grace period
+func pauseForDrawer(room *Room, drawer *Client) {
+	// mutex is already locked by caller function
+	room.DrawerLeftAt = time.Now()
+	state := room.GameState
+
+	broadcastChatMessage(room, systemMessage(room, "drawerDisconnected", drawer.Username))
+	broadcastEvent(room, "drawerDisconnected", map[string]interface{}{
+		"playerId":  drawer.ID,
+		"waitUntil": room.DrawerLeftAt.Add(reconnectGrace).UnixMilli(),
+	})
+
+	time.AfterFunc(reconnectGrace, func() {
+		defer recoverRoom(room, "drawer reconnect timer")
+
+		if skipDrawerTurn(room, state) {
+			endRound(room, state)
+		}
+	})
+}
+
+// skipDrawerTurn moves on from a drawer who didn't come back in time. It
+// reports whether the round still has to be ended to reveal the word
+func skipDrawerTurn(room *Room, state *GameState) bool {
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	// The drawer came back, or the round is already over
+	if room.GameState != state || !state.IsActive || room.DrawerLeftAt.IsZero() {
+		return false
+	}
+
+	room.DrawerLeftAt = time.Time{}
+	broadcastChatMessage(room, systemMessage(room, "drawerGone"))
+	broadcastEvent(room, "roundSkipped", map[string]interface{}{
+		"reason": "drawerLeft",
+	})
+
+	// Nothing to reveal if the word was never chosen
+	if state.CurrentWord == "" {
+		startNewRound(room)
+		return false
+	}
+	return true
+}
+
+// resumeDrawer continues a paused round once its drawer reconnects, with
+// the time they were away added back on
+func resumeDrawer(room *Room, drawer *Client) {
+	// mutex is already locked by caller function
+	if room.GameState.CurrentWord != "" {
+		away := time.Since(room.DrawerLeftAt)
+		room.RoundStartTime = room.RoundStartTime.Add(away)
+		moveDeadline(room, away)
+	}
+	room.DrawerLeftAt = time.Time{}
+	markDrawerActive(room)
+
+	clientLogf(drawer, "🎨 Drawer %s reconnected, resuming the round\n", drawer.Username)
+	broadcastChatMessage(room, systemMessage(room, "drawerBack", drawer.Username))
+	broadcastEvent(room, "drawerReturned", map[string]interface{}{
+		"playerId": drawer.ID,
+	})
+}
+
+// purgeDeparted forgets departed clients whose grace period is over
+func purgeDeparted(room *Room) {
+	// mutex is already locked by caller function
+	for token, departed := range room.Departed {
+		if time.Since(departed.LeftAt) > reconnectGrace {
+			delete(room.Departed, token)
+			room.Scores.Remove(departed.ID)
+		}
+	}
+}
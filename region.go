@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultRegionHeader = "CF-IPCountry" // Country code set by Cloudflare
+	maxRegionLength     = 16
+)
+
+// instanceRegion is the region this instance serves, set with REGION. When
+// it is empty a room takes the region of whoever opens it
+var instanceRegion = normalizeRegion(os.Getenv("REGION"))
+
+// regionHeader is the header a CDN or proxy puts the client's geolocated
+// region in, configurable with REGION_HEADER
+var regionHeader = regionHeaderName()
+
+func regionHeaderName() string {
+	if header := os.Getenv("REGION_HEADER"); header != "" {
+		return header
+	}
+	return defaultRegionHeader
+}
+
+// requestRegion is the region a connection counts as coming from
+func requestRegion(c *gin.Context) string {
+	if instanceRegion != "" {
+		return instanceRegion
+	}
+	return normalizeRegion(c.GetHeader(regionHeader))
+}
+
+// normalizeRegion only accepts short codes such as "DE" or "eu-west",
+// anything else counts as unknown
+func normalizeRegion(region string) string {
+	region = strings.TrimSpace(region)
+	if region == "" || len(region) > maxRegionLength {
+		return ""
+	}
+
+	for _, char := range region {
+		if char > unicode.MaxASCII || !(unicode.IsLetter(char) || unicode.IsDigit(char) || char == '-') {
+			return ""
+		}
+	}
+	return strings.ToUpper(region)
+}
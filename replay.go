@@ -0,0 +1,246 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	replayFormat     = "skribbl-replay/1"
+	maxReplayEvents  = 100000 // Events recorded per game, draw operations past it are dropped
+	replayFileSuffix = ".json"
+)
+
+// Replay is the recorded event stream of one game, exported as JSON for
+// replay viewers. Format "skribbl-replay/1":
+//
+//   - seed is what the room's random source was seeded with as the game began
+//   - players maps player IDs to the names they played under
+//   - events are in order, "t" is milliseconds since startedAt
+//   - "turnStarted": {round, turn, drawerId, tiebreaker, background, canvasWidth, canvasHeight}
+//   - "wordChosen": {word, bonusWord}
+//   - "draw": a stroke, erase, fill or clear, as sent to clients in draw messages.
+//     Clears carry "by", the ID of the drawer who cleared
+//   - "undo": the last draw operation of the turn is taken back
+//   - "correctGuess": {playerId, points}
+//   - "turnEnded": {word, scores}, scores are the totals so far by player ID
+//   - results are the final standings, as sent to clients at the end
+//
+// Chat isn't recorded, only correct guesses
+type Replay struct {
+	Format    string            `json:"format"`
+	ID        string            `json:"gameId"`
+	RoomCode  string            `json:"roomCode"`
+	Language  string            `json:"language"`
+	StartedAt time.Time         `json:"startedAt"`
+	EndedAt   time.Time         `json:"endedAt"`
+	Seed      int64             `json:"seed"`
+	Players   map[string]string `json:"players"`
+	Events    []ReplayEvent     `json:"events"`
+	Results   []Player          `json:"results"`
+	Truncated bool              `json:"truncated,omitempty"` // Draw operations were dropped past the event limit
+}
+
+type ReplayEvent struct {
+	Time  int64       `json:"t"`
+	Event string      `json:"event"`
+	Data  interface{} `json:"data,omitempty"`
+}
+
+// replayKey is where a finished game is stored
+func replayKey(roomCode string, id string) string {
+	return "replays/" + roomCode + "/" + id + replayFileSuffix
+}
+
+// deleteReplayPlayer takes a player's name out of every stored replay, what
+// they did stays in under their ID
+func deleteReplayPlayer(playerID string) error {
+	infos, err := blobs.List("replays/")
+	if err != nil {
+		return err
+	}
+
+	for _, info := range infos {
+		data, err := blobs.Get(info.Key)
+		if errors.Is(err, errBlobNotFound) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		var replay Replay
+		if err := json.Unmarshal(data, &replay); err != nil {
+			return err
+		}
+		if _, ok := replay.Players[playerID]; !ok {
+			continue
+		}
+
+		delete(replay.Players, playerID)
+		for i, result := range replay.Results {
+			if result.ID == playerID {
+				replay.Results[i].Username = ""
+			}
+		}
+
+		if data, err = json.Marshal(replay); err != nil {
+			return err
+		}
+		if err := blobs.Put(info.Key, data, jsonContentType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startReplay begins recording a new game
+func startReplay(room *Room) {
+	// mutex is already locked by caller function
+	room.Replay = &Replay{
+		Format:    replayFormat,
+		ID:        uuid.New().String(),
+		RoomCode:  room.Code,
+		Language:  room.Settings.Language,
+		StartedAt: time.Now(),
+		Seed:      room.Seed,
+		Players:   make(map[string]string),
+		Events:    []ReplayEvent{},
+	}
+}
+
+// recordReplay adds an event to the game being recorded, if any
+func recordReplay(room *Room, event string, data interface{}) {
+	// mutex is already locked by caller function
+	replay := room.Replay
+	if replay == nil {
+		return
+	}
+
+	if len(replay.Events) >= maxReplayEvents && (event == "draw" || event == "undo") {
+		replay.Truncated = true
+		return
+	}
+
+	replay.Events = append(replay.Events, ReplayEvent{
+		Time:  time.Since(replay.StartedAt).Milliseconds(),
+		Event: event,
+		Data:  data,
+	})
+}
+
+// recordTurnStart notes a new turn in the replay, along with the names of
+// everyone playing it
+func recordTurnStart(room *Room) {
+	// mutex is already locked by caller function
+	if room.Replay == nil {
+		return
+	}
+
+	for _, c := range room.Clients {
+		if c.Type != "spectator" {
+			room.Replay.Players[c.ID] = c.Username
+		}
+	}
+
+	state := room.GameState
+	recordReplay(room, "turnStarted", map[string]interface{}{
+		"round":        state.RoundNumber,
+		"turn":         state.TurnNumber,
+		"drawerId":     state.CurrentDrawer,
+		"tiebreaker":   state.Tiebreaker,
+		"background":   room.Settings.Background,
+		"canvasWidth":  state.CanvasWidth,
+		"canvasHeight": state.CanvasHeight,
+	})
+}
+
+// finishReplay archives the recorded game with its final standings and
+// tells the room where to download it. It returns the replay, if the game
+// was recorded
+func finishReplay(room *Room, results []Player) *Replay {
+	// mutex is already locked by caller function
+	replay := room.Replay
+	if replay == nil {
+		return nil
+	}
+	room.Replay = nil
+
+	replay.EndedAt = time.Now()
+	replay.Results = results
+
+	jsonData, err := json.Marshal(replay)
+	if err != nil {
+		captureError("marshal", err, map[string]interface{}{
+			"gameId": replay.ID,
+		})
+		return nil
+	}
+	putBlob(replayKey(replay.RoomCode, replay.ID), jsonData, jsonContentType)
+
+	broadcastEvent(room, "replayReady", map[string]interface{}{
+		"gameId": replay.ID,
+		"url":    publicURL + replayPath(replay),
+	})
+	return replay
+}
+
+func replayPath(replay *Replay) string {
+	return "/rooms/" + replay.RoomCode + "/replays/" + replay.ID + replayFileSuffix
+}
+
+// replaysHandler lists the IDs of a room's finished games that can be
+// downloaded
+func replaysHandler(c *gin.Context) {
+	code := c.Param("code")
+	ids := []string{}
+	if isBlobName(code) {
+		prefix := "replays/" + code + "/"
+		infos, err := blobs.List(prefix)
+		if err != nil {
+			logError(c.GetString("requestId"), "Failed to list replays", err)
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": "failed to list replays",
+			})
+			return
+		}
+
+		for _, info := range infos {
+			ids = append(ids, strings.TrimSuffix(strings.TrimPrefix(info.Key, prefix), replayFileSuffix))
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"replays": ids,
+	})
+}
+
+// replayHandler downloads a finished game as "<gameId>.json"
+func replayHandler(c *gin.Context) {
+	code := c.Param("code")
+	id, ok := strings.CutSuffix(c.Param("file"), replayFileSuffix)
+	if !ok || !isBlobName(code) || !isBlobName(id) {
+		respondUnknownMedia(c, "replay")
+		return
+	}
+
+	jsonData, err := blobs.Get(replayKey(code, id))
+	if errors.Is(err, errBlobNotFound) {
+		respondUnknownMedia(c, "replay")
+		return
+	} else if err != nil {
+		logError(c.GetString("requestId"), "Failed to load replay", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to load replay",
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+id+replayFileSuffix+`"`)
+	c.Data(http.StatusOK, jsonContentType, jsonData)
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const requestIDHeader = "X-Request-ID"
+
+// requestID tags every request with an ID, taken from the X-Request-ID
+// header if a proxy set one, and echoes it back. A WebSocket keeps the ID of
+// its upgrade request as its connection ID
+func requestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestIDHeader)
+		if id == "" || len(id) > 64 {
+			id = uuid.New().String()
+		}
+
+		c.Set("requestId", id)
+		c.Header(requestIDHeader, id)
+		c.Next()
+	}
+}
+
+// requestLogFormat is gin's default log line with the request ID added
+func requestLogFormat(param gin.LogFormatterParams) string {
+	return fmt.Sprintf("[GIN] %v | %3d | %13v | %15s | %-7s %#v | %s\n%s",
+		param.TimeStamp.Format(time.DateTime),
+		param.StatusCode,
+		param.Latency,
+		param.ClientIP,
+		param.Method,
+		redactPath(param.Path),
+		param.Keys["requestId"],
+		param.ErrorMessage,
+	)
+}
+
+// Query parameters that carry secrets: the admin feed's token, and the
+// reconnect and guest tokens players join with
+var secretParams = []string{"token", "guest"}
+
+// redactPath hides the secrets in a logged path's query
+func redactPath(path string) string {
+	base, query, ok := strings.Cut(path, "?")
+	if !ok {
+		return path
+	}
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return base + "?[unparsable query]"
+	}
+	for _, name := range secretParams {
+		if values.Has(name) {
+			values.Set(name, "redacted")
+		}
+	}
+	return base + "?" + values.Encode()
+}
+
+// clientLogf logs a line about a client, tagged with its connection ID so
+// everything about one connection can be found in the logs
+func clientLogf(client *Client, format string, args ...interface{}) {
+	log.Printf("[%s] "+format, append([]interface{}{client.ConnID}, args...)...)
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	webhookQueueSize = 100 // Deliveries waiting to be sent before dropping
+	webhookTimeout   = 10 * time.Second
+	webhookAttempts  = 3
+	webhookBackoff   = 5 * time.Second // Wait before the first retry, doubled after each
+)
+
+// Webhook is an outbound URL that is told about finished games. Deliveries
+// are signed with its secret in the X-Webhook-Signature header, as
+// "sha256=" and the hex HMAC-SHA256 of the body
+type Webhook struct {
+	URL    string `json:"url"`
+	Secret string `json:"secret,omitempty"`
+}
+
+// GameResults is what webhooks receive when a game ends
+type GameResults struct {
+	Event       string    `json:"event"` // Always "gameCompleted"
+	GameID      string    `json:"gameId,omitempty"`
+	RoomCode    string    `json:"roomCode"`
+	Language    string    `json:"language"`
+	TotalRounds int       `json:"totalRounds"`
+	EndedAt     time.Time `json:"endedAt"`
+	Results     []Player  `json:"results"` // Final standings, best first
+	ReplayURL   string    `json:"replayUrl,omitempty"`
+}
+
+type webhookDelivery struct {
+	webhook Webhook
+	body    []byte
+	client  *http.Client
+}
+
+// serverWebhook gets the results of every game, set with
+// RESULTS_WEBHOOK_URL and optionally RESULTS_WEBHOOK_SECRET
+var serverWebhook = Webhook{
+	URL:    os.Getenv("RESULTS_WEBHOOK_URL"),
+	Secret: os.Getenv("RESULTS_WEBHOOK_SECRET"),
+}
+
+var webhookDeliveries = startWebhooks()
+
+// Webhooks set by a room owner may only reach public addresses, so they
+// can't be pointed at services next to the server
+var (
+	serverWebhookClient = &http.Client{Timeout: webhookTimeout}
+	roomWebhookClient   = &http.Client{
+		Timeout: webhookTimeout,
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout: webhookTimeout,
+				Control: publicAddressOnly,
+			}).DialContext,
+		},
+	}
+)
+
+func publicAddressOnly(network string, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("webhook address %s is not public", host)
+	}
+	return nil
+}
+
+// startWebhooks delivers game results in the background, retrying a few
+// times with growing pauses when the receiver fails
+func startWebhooks() chan webhookDelivery {
+	deliveries := make(chan webhookDelivery, webhookQueueSize)
+
+	go func() {
+		for delivery := range deliveries {
+			backoff := webhookBackoff
+			for attempt := 1; ; attempt++ {
+				err := deliverWebhook(delivery)
+				if err == nil {
+					break
+				}
+				if attempt == webhookAttempts {
+					log.Printf("⚠️ Giving up on results webhook %s: %v\n", delivery.webhook.URL, err)
+					break
+				}
+				time.Sleep(backoff)
+				backoff *= 2
+			}
+		}
+	}()
+
+	return deliveries
+}
+
+func deliverWebhook(delivery webhookDelivery) error {
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.webhook.URL, bytes.NewReader(delivery.body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if delivery.webhook.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(delivery.webhook.Secret))
+		mac.Write(delivery.body)
+		request.Header.Set("X-Webhook-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	response, err := delivery.client.Do(request)
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+
+	if response.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook answered %s", response.Status)
+	}
+	return nil
+}
+
+// newRoomWebhook checks a URL a room owner gave and makes a webhook for it
+// with a fresh secret. Only https URLs are accepted
+func newRoomWebhook(rawURL string) (*Webhook, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Scheme != "https" || parsed.Host == "" || parsed.User != nil {
+		return nil, errors.New("the webhook must be an https URL")
+	}
+
+	return &Webhook{
+		URL:    parsed.String(),
+		Secret: uuid.New().String(),
+	}, nil
+}
+
+// sendResultsWebhooks posts a finished game's results to the server's
+// webhook and the room's own, if set
+func sendResultsWebhooks(room *Room, results []Player, replay *Replay) {
+	// mutex is already locked by caller function
+	if serverWebhook.URL == "" && room.ResultsWebhook == nil {
+		return
+	}
+
+	payload := GameResults{
+		Event:       "gameCompleted",
+		RoomCode:    room.Code,
+		Language:    room.Settings.Language,
+		TotalRounds: room.Round,
+		EndedAt:     time.Now(),
+		Results:     results,
+	}
+	if replay != nil {
+		payload.GameID = replay.ID
+		payload.ReplayURL = publicURL + replayPath(replay)
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		captureError("marshal", err, map[string]interface{}{
+			"type": "gameCompleted",
+		})
+		return
+	}
+
+	if serverWebhook.URL != "" {
+		queueWebhook(webhookDelivery{webhook: serverWebhook, body: body, client: serverWebhookClient})
+	}
+	if room.ResultsWebhook != nil {
+		queueWebhook(webhookDelivery{webhook: *room.ResultsWebhook, body: body, client: roomWebhookClient})
+	}
+}
+
+func queueWebhook(delivery webhookDelivery) {
+	select {
+	case webhookDeliveries <- delivery:
+	default:
+		log.Printf("⚠️ Results webhook queue is full, dropping delivery to %s\n", delivery.webhook.URL)
+	}
+}
+
+// setRoomWebhook handles an owner's setWebhook message. An empty URL turns
+// the webhook off, otherwise the owner is sent the signing secret
+func setRoomWebhook(room *Room, owner *Client, rawURL interface{}) {
+	// mutex is already locked by caller function
+	webhookURL, _ := rawURL.(string)
+	if webhookURL == "" {
+		room.ResultsWebhook = nil
+		sendEvent(room, owner, "webhookRemoved", nil)
+		return
+	}
+
+	webhook, err := newRoomWebhook(webhookURL)
+	if err != nil {
+		sendEvent(room, owner, "webhookRejected", map[string]interface{}{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	room.ResultsWebhook = webhook
+	clientLogf(owner, "🪝 %s set a results webhook\n", owner.Username)
+	sendEvent(room, owner, "webhookSet", map[string]interface{}{
+		"url":    webhook.URL,
+		"secret": webhook.Secret,
+	})
+}
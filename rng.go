@@ -0,0 +1,65 @@
+package main
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"log"
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ROOM_SEED pins the room's random source, so a test run draws the same
+// words, hints and turn order every time
+var pinnedSeed, seedPinned = loadPinnedSeed()
+
+func loadPinnedSeed() (int64, bool) {
+	v := os.Getenv("ROOM_SEED")
+	if v == "" {
+		return 0, false
+	}
+	seed, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		log.Printf("⚠️ Ignoring invalid ROOM_SEED %q\n", v)
+		return 0, false
+	}
+	return seed, true
+}
+
+// initialRoomSeed seeds the room's random source when the server starts
+func initialRoomSeed() int64 {
+	if seedPinned {
+		return pinnedSeed
+	}
+	return randomSeed()
+}
+
+// gameSeed seeds a new game. Drawing it from the room's own source would
+// make every game predictable from the first one, so it is only done when
+// ROOM_SEED pins the run
+func gameSeed(room *Room) int64 {
+	// mutex is already locked by caller function
+	if seedPinned {
+		return room.Rand.Int63()
+	}
+	return randomSeed()
+}
+
+// randomSeed comes from crypto/rand, or the clock if that fails
+func randomSeed() int64 {
+	var b [8]byte
+	if _, err := crand.Read(b[:]); err != nil {
+		return time.Now().UnixNano()
+	}
+	return int64(binary.LittleEndian.Uint64(b[:]) >> 1)
+}
+
+// seedRoom gives the room a fresh random source. Everything random about a
+// game, from word choices to power-ups, is drawn from it, so a game can be
+// played back the same way from its seed
+func seedRoom(room *Room, seed int64) {
+	// mutex is already locked by caller function
+	room.Seed = seed
+	room.Rand = rand.New(rand.NewSource(seed))
+}
@@ -3,32 +3,214 @@ package main
 import (
 	"log"
 	"time"
+
+	"github.com/gorilla/websocket"
 )
 
-func endRound(room *Room) {
+// endRound ends the turn state belongs to. It is called without the lock
+// held, so the turn may already have ended or moved on by the time it gets
+// it, and then there is nothing left to do
+func endRound(room *Room, state *GameState) {
 	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if room.GameState != state || !state.IsActive {
+		return
+	}
+
+	// The reveal and the final state go out as one tick
+	if beginBatch(room) {
+		defer flushBatch(room)
+	}
+
 	wordToReveal := room.GameState.CurrentWord
+	bonusWordToReveal := ""
+	if room.GameState.BonusWordFoundBy == "" {
+		bonusWordToReveal = room.GameState.CurrentBonusWord
+	}
 	room.GameState.IsActive = false
-	room.mu.Unlock()
+	room.GameState.Phase = PhaseIntermission
+	room.Poll = nil
+	resetStreaks(room)
+
+	// The round can end before a word was even chosen
+	if wordToReveal != "" {
+		broadcastChatMessage(room, systemMessage(room, "wordReveal", wordToReveal))
+		broadcastWordReveal(room, bonusWordToReveal, saveDrawing(room))
+	}
 
-	broadcastChatMessage(room, ChatMessage{
-		Username: "System",
-		Message:  "The word was: " + wordToReveal,
-		IsSystem: true,
+	if bonusWordToReveal != "" {
+		broadcastChatMessage(room, systemMessage(room, "bonusWordMissed", bonusWordToReveal))
+	}
+
+	broadcastEvent(room, "roundEnded", map[string]interface{}{
+		"word":      wordToReveal,
+		"bonusWord": bonusWordToReveal,
+	})
+
+	guessers := 0
+	for id := range room.Clients {
+		if canGuess(room, id) {
+			guessers++
+		}
+	}
+	roundStats := map[string]interface{}{
+		"word":        wordToReveal,
+		"drawerId":    room.GameState.CurrentDrawer,
+		"roundNumber": room.GameState.RoundNumber,
+		"turnNumber":  room.GameState.TurnNumber,
+		"guessers":    guessers,
+		"guessed":     len(room.GameState.PlayersGuessed),
+	}
+	if wordToReveal != "" {
+		roundStats["duration"] = time.Since(room.RoundStartTime).Milliseconds()
+	}
+	trackEvent(room, "round_ended", roundStats)
+	recordReplay(room, "turnEnded", map[string]interface{}{
+		"word":   wordToReveal,
+		"scores": room.Scores.Snapshot(),
 	})
+	recordWordStats(room, guessers)
+
+	// The word stays revealed for the intermission, clients count it down
+	intermission := time.Duration(room.Settings.Intermission) * time.Second
+	room.GameState.IntermissionEndsAt = time.Now().Add(intermission).UnixMilli()
+	broadcastEvent(room, "intermission", map[string]interface{}{
+		"seconds": room.Settings.Intermission,
+		"endsAt":  room.GameState.IntermissionEndsAt,
+	})
+	openCategoryVote(room, time.Now().Add(intermission))
 
 	broadcastGameState(room)
 
-	// Start new round after delay
-	go func() {
-		time.Sleep(5 * time.Second)
+	// Start new round after the intermission, unless the game was reset or
+	// moved on in the meantime
+	state = room.GameState
+	goRoom(room, "next round", func() {
+		time.Sleep(intermission)
 		room.mu.Lock()
-		if len(room.Clients) >= 2 {
+		defer room.mu.Unlock()
+
+		if room.GameState != state {
+			log.Println("⏸️ Game moved on, not starting the next round")
+		} else if room.Settings.ManualTurns && countPlayers(room) >= 2 {
+			log.Println("⏸️ Waiting for the owner to start the next round")
+			if beginBatch(room) {
+				defer flushBatch(room)
+			}
+			room.GameState.WaitingForOwner = true
+			room.GameState.IntermissionEndsAt = 0
+			broadcastChatMessage(room, systemMessage(room, "waitingForOwner"))
+			broadcastGameState(room)
+		} else if countPlayers(room) >= 2 {
 			log.Println("🔄 Auto-starting next round...")
+			if beginBatch(room) {
+				defer flushBatch(room)
+			}
 			startNewRound(room)
 		} else {
 			log.Println("⏸️ Not enough players for next round")
+			room.GameState.Phase = PhaseLobby
+			room.GameState.IntermissionEndsAt = 0
+			broadcastGameState(room)
 		}
-		room.mu.Unlock()
-	}()
+	})
+}
+
+// resetGame abandons the current game and puts the room back in the lobby:
+// timers are stopped, the canvas and scores are cleared, and everyone gets
+// the clean lobby state in one tick. With keepScores on, scores and game
+// progress survive so play can resume once enough players are back
+func resetGame(room *Room) {
+	// mutex is already locked by caller function
+	batched := beginBatch(room)
+
+	// A new GameState stops the round timer, the drawer reconnect timer and
+	// a pending next round, which all check it is still theirs
+	room.GameState = &GameState{
+		IsActive:       false,
+		Phase:          PhaseLobby,
+		CanvasWidth:    room.Settings.CanvasWidth,
+		CanvasHeight:   room.Settings.CanvasHeight,
+		PlayersGuessed: make(map[string]bool),
+	}
+	room.CurrentDrawer = ""
+	room.DrawerLeftAt = time.Time{}
+	room.Poll = nil
+	room.CategoryVote = nil
+	room.NextCategory = ""
+	room.SkipVotes = make(map[string]bool)
+
+	if room.AutoStartTimer != nil {
+		room.AutoStartTimer.Stop()
+		room.AutoStartTimer = nil
+	}
+
+	for _, c := range room.Clients {
+		c.FrozenUntil = time.Time{}
+	}
+
+	// Reset all scores, streaks, stats, power-ups and progress
+	if !room.Settings.KeepScores {
+		room.Scores.Reset()
+		for _, c := range room.Clients {
+			c.Streak = 0
+			c.Powerups = nil
+			c.Stats = PlayerStats{}
+		}
+
+		// An abandoned game never gets a replay
+		room.Replay = nil
+		resetTurns(room)
+		room.TiebreakerPlayers = nil
+		room.TiebreakerTurns = 0
+	}
+
+	log.Println("🧹 Game reset, back to the lobby")
+
+	broadcastGameState(room)
+	broadcastPlayers(room)
+	clearCanvas(room)
+
+	if batched {
+		flushBatch(room)
+	}
+}
+
+// stopGame ends a game where it stands: the results go out as if it had
+// run its course, and the room goes back to the lobby
+func stopGame(room *Room) {
+	// mutex is already locked by caller function
+	sendFinalResults(room)
+	resetGame(room)
+}
+
+// closeRoom ends the room for everyone: they get a closing notice and a
+// close frame, can't reconnect, and the game is reset, which stops its
+// timers. Creating the room over REST starts it afresh
+func closeRoom(room *Room) {
+	// mutex is already locked by caller function
+	broadcastChatMessage(room, systemMessage(room, "roomClosing"))
+	broadcastEvent(room, "roomClosed", nil)
+
+	// The notice has to go out before the connections close
+	flushBatch(room)
+
+	for _, client := range room.Clients {
+		// Kicked clients aren't remembered for a reconnect
+		client.Kicked = true
+		client.Conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.CloseGoingAway, "room closed"),
+			time.Now().Add(time.Second))
+		client.Conn.Close()
+	}
+
+	room.Departed = make(map[string]*DepartedClient)
+	room.OwnerSecret = ""
+	room.ResultsWebhook = nil
+	room.RecentChat = nil
+	room.FixedDrawer = ""
+	resetGame(room)
+
+	log.Println("🏚️ Room closed")
 }
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func setupRoomRoutes(router *gin.Engine) {
+	router.POST("/room", roomRateLimit(), createRoomHandler)
+
+	owner := router.Group("/room", ownerAuth())
+	owner.PATCH("/settings", roomSettingsHandler)
+	owner.POST("/kick", roomKickHandler)
+	owner.PUT("/webhook", roomWebhookHandler)
+	owner.DELETE("/webhook", deleteRoomWebhookHandler)
+	owner.DELETE("", closeRoomHandler)
+}
+
+// ownerAuth only lets requests through that carry the room's owner secret
+// as a bearer token
+func ownerAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		given := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+
+		room.mu.RLock()
+		secret := room.OwnerSecret
+		room.mu.RUnlock()
+
+		if secret == "" || subtle.ConstantTimeCompare([]byte(given), []byte(secret)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "unauthorized",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// createRoomHandler sets up the room over REST, optionally with settings in
+// the body, which may name a preset to start from, and returns the owner
+// secret that authorizes managing it. The room has to be empty, creating it
+// again hands out a new secret
+func createRoomHandler(c *gin.Context) {
+	// The body is optional, but when there is one it has to be settings
+	var settings map[string]interface{}
+	if err := c.ShouldBindJSON(&settings); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "settings must be a JSON object",
+		})
+		return
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if len(room.Clients) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "the room is in use",
+		})
+		return
+	}
+
+	room.OwnerSecret = uuid.New().String()
+	if settings != nil {
+		updateSettings(room, settings)
+	}
+
+	log.Println("🏠 Room created over REST")
+	c.JSON(http.StatusCreated, gin.H{
+		"ownerSecret": room.OwnerSecret,
+		"settings":    room.Settings,
+	})
+}
+
+// roomSettingsHandler changes settings like the owner's updateSettings
+// message, and likewise not in the middle of a game
+func roomSettingsHandler(c *gin.Context) {
+	var settings map[string]interface{}
+	if err := c.ShouldBindJSON(&settings); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "settings must be a JSON object",
+		})
+		return
+	}
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	if room.GameState.IsActive {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "settings can't change during a game",
+		})
+		return
+	}
+
+	if beginBatch(room) {
+		defer flushBatch(room)
+	}
+	updateSettings(room, settings)
+	broadcastSettings(room)
+	checkAutoStart(room)
+
+	c.JSON(http.StatusOK, gin.H{
+		"settings": room.Settings,
+	})
+}
+
+func roomKickHandler(c *gin.Context) {
+	var request struct {
+		PlayerID string `json:"playerId"`
+	}
+	c.ShouldBindJSON(&request)
+
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	target, ok := room.Clients[request.PlayerID]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "unknown player",
+		})
+		return
+	}
+
+	if beginBatch(room) {
+		defer flushBatch(room)
+	}
+	kickClient(room, target)
+
+	c.JSON(http.StatusOK, gin.H{
+		"kicked": target.ID,
+	})
+}
+
+func closeRoomHandler(c *gin.Context) {
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	closeRoom(room)
+	c.JSON(http.StatusOK, gin.H{
+		"closed": true,
+	})
+}
+
+// roomWebhookHandler sets the URL the results of each game are posted to,
+// and returns the secret deliveries are signed with
+func roomWebhookHandler(c *gin.Context) {
+	var request struct {
+		URL string `json:"url"`
+	}
+	c.ShouldBindJSON(&request)
+
+	webhook, err := newRoomWebhook(request.URL)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	room.mu.Lock()
+	room.ResultsWebhook = webhook
+	room.mu.Unlock()
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+func deleteRoomWebhookHandler(c *gin.Context) {
+	room.mu.Lock()
+	room.ResultsWebhook = nil
+	room.mu.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{
+		"deleted": true,
+	})
+}
@@ -0,0 +1,98 @@
+package main
+
+import "sync"
+
+type ScoreEvent struct {
+	PlayerID string `json:"playerId"`
+	Points   int    `json:"points"`
+	Total    int    `json:"total"`
+	Reason   string `json:"reason"`
+}
+
+// ScoreBoard owns every player's score. All changes go through it so they
+// are locked and reported in one place
+type ScoreBoard struct {
+	mu     sync.Mutex
+	scores map[string]int
+
+	// Called after every change, outside the scoreboard's lock
+	OnChange func(event ScoreEvent)
+}
+
+func NewScoreBoard() *ScoreBoard {
+	return &ScoreBoard{
+		scores: make(map[string]int),
+	}
+}
+
+// Award adds points to a player's score and returns the new total
+func (s *ScoreBoard) Award(playerID string, points int, reason string) int {
+	s.mu.Lock()
+	s.scores[playerID] += points
+	total := s.scores[playerID]
+	s.mu.Unlock()
+
+	s.emit(ScoreEvent{
+		PlayerID: playerID,
+		Points:   points,
+		Total:    total,
+		Reason:   reason,
+	})
+
+	return total
+}
+
+func (s *ScoreBoard) Score(playerID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.scores[playerID]
+}
+
+// Reset zeroes every score
+func (s *ScoreBoard) Reset() {
+	s.mu.Lock()
+	events := []ScoreEvent{}
+	for playerID, score := range s.scores {
+		if score != 0 {
+			events = append(events, ScoreEvent{
+				PlayerID: playerID,
+				Points:   -score,
+				Total:    0,
+				Reason:   "reset",
+			})
+		}
+	}
+	s.scores = make(map[string]int)
+	s.mu.Unlock()
+
+	for _, event := range events {
+		s.emit(event)
+	}
+}
+
+// Remove forgets a player who left the room
+func (s *ScoreBoard) Remove(playerID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.scores, playerID)
+}
+
+// Snapshot returns a copy of all scores
+func (s *ScoreBoard) Snapshot() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := make(map[string]int, len(s.scores))
+	for playerID, score := range s.scores {
+		snapshot[playerID] = score
+	}
+	return snapshot
+}
+
+func (s *ScoreBoard) emit(event ScoreEvent) {
+	if s.OnChange != nil {
+		s.OnChange(event)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+const (
+	ScoringFlat  = "flat"  // Every correct guess is worth the same
+	ScoringSpeed = "speed" // Guesses are worth more the more time is left
+	ScoringOrder = "order" // The first to guess gets the most, each after a little less
+
+	flatGuessPoints = 100
+	maxGuessPoints  = 150
+	minGuessPoints  = 50
+	orderPointsStep = 25 // Points lost per player who guessed first
+)
+
+func isValidScoring(scoring string) bool {
+	switch scoring {
+	case ScoringFlat, ScoringSpeed, ScoringOrder:
+		return true
+	}
+	return false
+}
+
+// guessPoints is what a correct guess made right now is worth under the
+// room's scoring profile
+func guessPoints(room *Room) int {
+	// mutex is already locked by caller function
+	switch room.Settings.Scoring {
+	case ScoringSpeed:
+		roundTime := max(room.GameState.RoundTime, 1)
+		remaining := min(max(roundRemaining(room), 0), roundTime)
+		timeBonus := (maxGuessPoints - minGuessPoints) * remaining / roundTime
+		return minGuessPoints + timeBonus*timeBonusFactor(room)
+
+	case ScoringOrder:
+		return max(maxGuessPoints-orderPointsStep*len(room.GameState.PlayersGuessed), minGuessPoints)
+	}
+	return flatGuessPoints
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"strconv"
+)
+
+const maxRecentChat = 50 // Chat messages kept for clients that resync
+
+// Every broadcast to the room carries a "seq" number that goes up by one
+// each time, so a client that sees a number skip knows it missed something
+// and can ask for a resync. Relays that leave the sender out, like draw
+// operations, carry the number of the last broadcast without moving it on
+
+// withSeq stamps a marshaled message with a sequence number
+func withSeq(jsonData []byte, seq uint64) []byte {
+	if len(jsonData) == 0 || jsonData[0] != '{' {
+		return jsonData
+	}
+
+	// Sized for the longest sequence number, so stamping allocates once
+	stamped := make([]byte, 0, len(jsonData)+len(`{"seq":,`)+20)
+	stamped = append(stamped, `{"seq":`...)
+	stamped = strconv.AppendUint(stamped, seq, 10)
+	if !bytes.Equal(jsonData, []byte("{}")) {
+		stamped = append(stamped, ',')
+	}
+	return append(stamped, jsonData[1:]...)
+}
+
+// rememberChat keeps a chat message everyone saw for clients that resync
+func rememberChat(room *Room, chatMsg ChatMessage) {
+	// mutex is already locked by caller function
+	room.RecentChat = append(room.RecentChat, chatMsg)
+	if len(room.RecentChat) > maxRecentChat {
+		room.RecentChat = append([]ChatMessage{}, room.RecentChat[len(room.RecentChat)-maxRecentChat:]...)
+	}
+}
+
+// sendResync catches a client up after a reconnect or missed messages, in
+// one response: the players, the game state, the canvas from its latest
+// keyframe and the recent chat, with the sequence number they are current
+// as of
+func sendResync(room *Room, client *Client) {
+	// mutex is already locked by caller function
+	view := buildClientView(room, client)
+	state := publicGameState(room)
+	if view.HideTimer {
+		state.TimeRemaining = 0
+		state.RoundEndsAt = 0
+	}
+
+	sendMessage(client, Message{
+		Type: "resync",
+		Data: map[string]interface{}{
+			"seq":       room.Seq.Load(),
+			"players":   playerList(room),
+			"gameState": &state,
+			"canvas":    canvasMessage(room).Data,
+			"chat":      append([]ChatMessage{}, room.RecentChat...),
+		},
+	})
+
+	// Deltas build on the last broadcast, which may not match what this
+	// client had, and the private view goes out again in full
+	client.NeedsFullState = true
+	client.PrivateView = nil
+	sendPrivateView(client, view)
+}
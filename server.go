@@ -3,7 +3,6 @@ package main
 import (
 	"encoding/json"
 	"log"
-	"math/rand"
 	"net/http"
 	"os"
 	"strings"
@@ -23,8 +22,18 @@ var upgrader = websocket.Upgrader{
 }
 
 var room = &Room{
-	Clients:   make(map[string]*Client),
-	GameState: &GameState{IsActive: false},
+	Clients: make(map[string]*Client),
+	GameState: &GameState{
+		IsActive:     false,
+		Phase:        PhaseLobby,
+		CanvasWidth:  defaultCanvasWidth,
+		CanvasHeight: defaultCanvasHeight,
+	},
+	Settings:  defaultSettings(),
+	Scores:    NewScoreBoard(),
+	Departed:  make(map[string]*DepartedClient),
+	KickVotes: make(map[string]map[string]bool),
+	SkipVotes: make(map[string]bool),
 }
 
 func wsHandler(c *gin.Context) {
@@ -34,6 +43,22 @@ func wsHandler(c *gin.Context) {
 		username = "Anonymous"
 	}
 
+	// Spectators watch with the word visible but never draw or guess
+	spectate := c.Query("spectate") == "true"
+
+	// Token from an earlier connection, to come back as the same player
+	token := c.Query("token")
+
+	// Signed guest token from an earlier visit, keeps the player ID stable
+	// across sessions
+	guestID, isGuest := verifyGuestToken(c.Query("guest"))
+
+	// Language for the room, only used by whoever opens an empty room
+	language := c.Query("language")
+
+	// Region the room is tagged with when this connection opens it
+	region := requestRegion(c)
+
 	// Upgrade to WebSocket
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
@@ -41,111 +66,269 @@ func wsHandler(c *gin.Context) {
 	}
 	defer conn.Close()
 
-	// Create new client with UUID
+	// Create new client with UUID, or the ID a returning guest had
 	clientID := uuid.New().String()
+	if isGuest {
+		clientID = guestID
+	}
+
+	// Pinned for the log lines of this connection, even if the session
+	// moves on to a newer one
+	connID := c.GetString("requestId")
 
 	client := &Client{
 		ID:       clientID,
+		Token:    uuid.New().String(),
 		Conn:     conn,
+		ConnID:   connID,
+		JoinedAt: time.Now(),
 		Username: username,
 		Type:     "player",
-		Score:    0,
+		Guest:    isGuest,
+	}
+
+	if spectate {
+		client.Type = "spectator"
 	}
 
-	// if no player is present then make this player the owner of room
 	room.mu.Lock()
-	if len(room.Clients) == 0 {
-		client.Type = "owner"
+
+	existing := liveSession(room, token)
+	if existing == nil && isGuest {
+		existing = room.Clients[guestID]
 	}
 
-	// Add client to room
-	addClientToRoom(room, client)
-	log.Printf("🔌 Client connected: %s [%s] (Total clients: %d)\n", username, clientID, len(room.Clients))
+	if existing != nil {
+		// The same player connecting twice takes over their existing
+		// session instead of joining as a second player
+		takeOverSession(existing, conn, connID)
+		client = existing
+		clientID = client.ID
+		username = client.Username
+	} else {
+		// A client reconnecting within the grace period keeps their identity
+		departed, reconnected := reclaimSession(room, token)
+		if !reconnected && isGuest {
+			token = departedGuest(room, guestID)
+			departed, reconnected = reclaimSession(room, token)
+		}
+		if reconnected {
+			client.Token = token
+			restoreClient(room, client, departed)
+			clientID = client.ID
+		}
+
+		// A saved profile's display name wins over the one asked for
+		applyProfile(client)
+		username = client.Username
+
+		// The first player in an empty room picks its language
+		if client.Type == "player" && countPlayers(room) == 0 && !room.GameState.IsActive &&
+			isValidLanguage(language) && language != room.Settings.Language {
+			room.Settings.Language = language
+			broadcastSettings(room)
+		}
+
+		// if no owner is present then make this player the owner of room
+		if client.Type == "player" && !hasOwner(room) {
+			client.Type = "owner"
+		}
+
+		// Let everyone know who's here
+		joinMessage := "playerJoined"
+		if reconnected {
+			joinMessage = "playerReconnected"
+		} else if client.Type == "spectator" {
+			joinMessage = "playerSpectating"
+		}
+		broadcastChatMessage(room, systemMessage(room, joinMessage, username))
+		broadcastEvent(room, joinMessage, map[string]interface{}{
+			"playerId": client.ID,
+			"username": username,
+		})
+
+		// Add client to room
+		addClientToRoom(room, client)
+		if len(room.Clients) == 1 {
+			room.Region = region
+			room.Code = newRoomCode(room)
+			room.RecentChat = nil
+			room.FixedDrawer = ""
+			assignVariant(room)
+		}
+		if !reconnected {
+			joinRotation(room, client)
+		}
+		if client.Type != "spectator" && room.Settings.Teams > 0 && room.Teams != nil {
+			joinTeam(room, client.ID)
+			broadcastTeams(room)
+		}
+		publishPlayerCount(room)
+		checkAutoStart(room)
+	}
+	clientLogf(client, "🔌 Client connected: %s [%s] (Total clients: %d)\n", username, clientID, len(room.Clients))
 	room.mu.Unlock()
 
 	// Send connection confirmation with client ID to the new client
 	connMessage := Message{
 		Type: "connected",
 		Data: map[string]interface{}{
-			"clientId": clientID,
-			"username": username,
-			"type":     client.Type,
+			"clientId":     clientID,
+			"connectionId": connID,
+			"username":     username,
+			"type":         client.Type,
+			"token":        client.Token,
+			"guestToken":   issueGuestToken(clientID),
 		},
 	}
 	connJSON, _ := json.Marshal(connMessage)
-	client.Conn.WriteMessage(websocket.TextMessage, connJSON)
+	writeFrame(client, connJSON)
 
 	// Broadcast updated players list to all clients
 	broadcastPlayers(room)
 
-	// Send current game state to new player
+	// Send current game state and room settings to new player
 	sendGameState(client)
+	sendSettings(client)
+	sendCanvas(client)
+	sendServerTime(client, nil)
+
+	// Measure round-trip time until the client disconnects
+	stopPinger := startPinger(client)
+	defer close(stopPinger)
 
 	// Remove client from room on disconnect
 	defer func() {
 		room.mu.Lock()
+
+		// The session moved to a newer connection, it isn't leaving
+		if client.Conn != conn {
+			room.mu.Unlock()
+			return
+		}
+
+		// Kicked players don't get to reconnect, and were announced already
+		if !client.Kicked {
+			rememberDeparted(room, client)
+		}
 		removeClientFromRoom(room, clientID)
+		publishPlayerCount(room)
 
-		// Reset game if less than 2 players remain
-		if len(room.Clients) < 2 && room.GameState.IsActive {
-			room.GameState = &GameState{
-				IsActive: false,
-			}
-			// Reset all scores
-			for _, c := range room.Clients {
-				c.Score = 0
-			}
+		if !client.Kicked {
+			broadcastChatMessage(room, systemMessage(room, "playerLeft", client.Username))
+			broadcastEvent(room, "playerLeft", map[string]interface{}{
+				"playerId": clientID,
+				"username": client.Username,
+			})
+		}
 
-			room.GameState.PlayersGuessed = make(map[string]bool)
+		// The next state update drops them from the drawers coming up
+		if room.GameState.IsActive && !room.GameState.Tiebreaker {
+			room.GameState.UpNext = upcomingDrawers(room)
+		}
 
+		// Give a disconnected drawer the chance to come back to their round
+		if room.GameState.IsActive && clientID == room.GameState.CurrentDrawer && countPlayers(room) >= 2 {
+			pauseForDrawer(room, client)
 		}
 
+		// Reset game if less than 2 players remain, also between turns.
+		// Spectators don't count, they can't keep a game going
+		reset := countPlayers(room) < 2 && (room.GameState.IsActive || room.Round > 0)
+		if reset {
+			resetGame(room)
+		}
+
+		// A round where only the drawer and spectators are left can't be won
+		unplayable := room.GameState.IsActive && room.DrawerLeftAt.IsZero() && !hasGuessers(room)
+		if unplayable {
+			broadcastChatMessage(room, systemMessage(room, "noGuessersLeft"))
+			broadcastEvent(room, "roundSkipped", map[string]interface{}{
+				"reason": "noGuessers",
+			})
+		}
+
+		// Broadcast updated players list after disconnect, a reset
+		// already sent it along with the lobby state
+		if !reset {
+			broadcastPlayers(room)
+		}
+
+		state := room.GameState
 		room.mu.Unlock()
-		// Broadcast updated players list after disconnect
-		broadcastPlayers(room)
 
-		// Broadcast game state if it was reset
-		if len(room.Clients) < 2 {
-			broadcastGameState(room)
+		if unplayable {
+			endRound(room, state)
 		}
 	}()
 
 	for {
 		_, msg, err := conn.ReadMessage()
 		if err != nil {
-			log.Printf("read error: %v\n", err)
+			log.Printf("[%s] read error: %v\n", connID, err)
 			return
 		}
 
 		var message Message
 		err = json.Unmarshal(msg, &message)
 		if err != nil {
-			log.Printf("unmarshal error: %v\n", err)
+			log.Printf("[%s] unmarshal error: %v\n", connID, err)
 			continue
 		}
 
-		handleMessage(client, message)
+		handleMessageSafely(client, message)
 	}
 }
 
 func handleMessage(client *Client, message Message) {
 	room.mu.Lock()
 
+	// Everything sent while handling the message goes out as one tick
+	batched := beginBatch(room)
+
 	// Flag to track mutex is unlocked
 	unlocked := false
 	defer func() {
 		if !unlocked {
+			if batched {
+				flushBatch(room)
+			}
 			room.mu.Unlock()
 		}
 	}()
 
 	switch message.Type {
 	case "draw":
-		// Only allow current drawer to send draw data
-		if room.GameState.IsActive && client.ID == room.GameState.CurrentDrawer {
-			broadcastToOthers(room, client.ID, message)
+		// Only the drawer draws, and only while their word is up
+		if !canDraw(room, client) {
+			recordOutOfPhaseDraw(room, client)
+			return
+		}
+
+		markDrawerActive(room)
+		handleDraw(room, client, message)
+
+	case "clear":
+		if !canDraw(room, client) {
+			recordOutOfPhaseDraw(room, client)
+			return
 		}
 
+		markDrawerActive(room)
+		clearDrawing(room, client)
+
+	case "timeSync":
+		data, _ := message.Data.(map[string]interface{})
+		sendServerTime(client, data["clientTime"])
+
+	case "canvasSync":
+		// Clients that missed draw operations rebuild from the keyframe
+		sendMessage(client, canvasMessage(room))
+
+	case "resync":
+		// Clients that noticed a gap in sequence numbers catch up in full
+		sendResync(room, client)
+
 	case "chat":
 		data, ok := message.Data.(map[string]interface{})
 		if !ok {
@@ -157,47 +340,176 @@ func handleMessage(client *Client, message Message) {
 			return
 		}
 
+		// Commands are run, not broadcast
+		if strings.HasPrefix(chatMsg, "/") {
+			handleChatCommand(room, client, chatMsg)
+			return
+		}
+
+		logChat(client, chatMsg)
+
+		// Spectators know the word, so they only talk among themselves
+		if client.Type == "spectator" {
+			sendToSpectators(room, Message{
+				Type: "chat",
+				Data: ChatMessage{
+					Username: client.Username,
+					Message:  chatMsg,
+					IsSystem: false,
+				},
+			})
+			return
+		}
+
+		// Frozen guessers have to wait it out
+		if room.GameState.IsActive && canGuess(room, client.ID) && isFrozen(client) {
+			sendChatMessage(client, systemMessage(room, "youAreFrozen"))
+			sendEvent(room, client, "messageBlocked", map[string]interface{}{
+				"reason": "frozen",
+			})
+			return
+		}
+
+		// So do guessers on a cooldown for flooding wrong guesses
+		if room.GameState.IsActive && canGuess(room, client.ID) && isCoolingDown(client) {
+			sendChatMessage(client, systemMessage(room, "slowDown"))
+			sendEvent(room, client, "messageBlocked", map[string]interface{}{
+				"reason": "cooldown",
+			})
+			return
+		}
+
+		// Spectators of a tiebreaker can't guess, and mustn't leak the word
+		if room.GameState.IsActive && room.GameState.Tiebreaker && client.ID != room.GameState.CurrentDrawer &&
+			!canGuess(room, client.ID) && guessMatches(room, chatMsg, room.GameState.CurrentWord) {
+			sendChatMessage(client, systemMessage(room, "tiebreakerOnly"))
+			sendEvent(room, client, "messageBlocked", map[string]interface{}{
+				"reason": "tiebreaker",
+			})
+			return
+		}
+
 		// Check if message is correct guess
-		if room.GameState.IsActive && client.ID != room.GameState.CurrentDrawer {
+		if room.GameState.IsActive && canGuess(room, client.ID) {
 
 			// check in small case
-			if strings.EqualFold(chatMsg, room.GameState.CurrentWord) && room.GameState.PlayersGuessed[client.ID] != true {
+			if guessMatches(room, chatMsg, room.GameState.CurrentWord) && room.GameState.PlayersGuessed[client.ID] != true {
 				// Correct guess!
-				client.Score += 100
+				points := guessPoints(room)
+				room.Scores.Award(client.ID, points, "correctGuess")
+				awardStreak(room, client)
+				sendGuessResult(client, chatMsg, GuessCorrect)
 
 				// Broadcast correct guess notification
-				broadcastChatMessage(room, ChatMessage{
-					Username: "System",
-					Message:  client.Username + " guessed the word!",
-					IsSystem: true,
+				broadcastChatMessage(room, systemMessage(room, "guessedWord", client.Username))
+				broadcastEvent(room, "correctGuess", map[string]interface{}{
+					"playerId": client.ID,
+					"username": client.Username,
+					"points":   points,
+				})
+				recordReplay(room, "correctGuess", map[string]interface{}{
+					"playerId": client.ID,
+					"points":   points,
+				})
+				timeToGuess := time.Since(room.RoundStartTime).Milliseconds()
+				room.GameState.GuessTimes = append(room.GameState.GuessTimes, timeToGuess)
+				room.GameState.GuessOrder = append(room.GameState.GuessOrder, client.ID)
+				client.Stats.WordsGuessed++
+				client.Stats.GuessTime += timeToGuess
+				if client.Stats.FastestGuess == 0 || timeToGuess < client.Stats.FastestGuess {
+					client.Stats.FastestGuess = timeToGuess
+				}
+				trackEvent(room, "guess_correct", map[string]interface{}{
+					"word":        room.GameState.CurrentWord,
+					"playerId":    client.ID,
+					"timeToGuess": timeToGuess,
+					"guessOrder":  len(room.GameState.PlayersGuessed) + 1,
 				})
 
 				// Update players list with new score
 				broadcastPlayers(room)
 
-				// Mark player as having guessed
+				// Mark player as having guessed, they get to see the word now
 				room.GameState.PlayersGuessed[client.ID] = true
+				sendPrivateView(client, buildClientView(room, client))
+				shortenRound(room)
 
 				// check if all players have guessed the word then end if so
 
 				allGuessed := true
 				for _, c := range room.Clients {
-					if c.ID != room.GameState.CurrentDrawer && !room.GameState.PlayersGuessed[c.ID] {
+					if canGuess(room, c.ID) && !room.GameState.PlayersGuessed[c.ID] {
 						allGuessed = false
 						break
 					}
 				}
 
 				// End round - must unlock before calling since endRound spawns goroutine
+				state := room.GameState
+				if batched {
+					flushBatch(room)
+				}
 				room.mu.Unlock()
 				unlocked = true
 
 				if allGuessed {
-					endRound(room)
+					endRound(room, state)
 				}
 
 				return
 			}
+
+			// The chat below only reaches those who know the word already
+			if room.GameState.PlayersGuessed[client.ID] && guessMatches(room, chatMsg, room.GameState.CurrentWord) {
+				sendGuessResult(client, chatMsg, GuessAlreadyGuessed)
+			}
+		}
+
+		// Check if message is the bonus word, only the first finder scores
+		if room.GameState.IsActive && canGuess(room, client.ID) && room.GameState.CurrentBonusWord != "" &&
+			room.GameState.BonusWordFoundBy == "" && guessMatches(room, chatMsg, room.GameState.CurrentBonusWord) {
+			room.Scores.Award(client.ID, 50, "bonusWord")
+			room.GameState.BonusWordFoundBy = client.ID
+			room.GameState.BonusWord = room.GameState.CurrentBonusWord
+
+			broadcastChatMessage(room, systemMessage(room, "foundBonusWord", client.Username, room.GameState.BonusWord))
+			broadcastEvent(room, "bonusWordFound", map[string]interface{}{
+				"playerId": client.ID,
+				"username": client.Username,
+				"word":     room.GameState.BonusWord,
+				"points":   50,
+			})
+
+			broadcastPlayers(room)
+			broadcastGameState(room)
+			return
+		}
+
+		// Count wrong guesses while the word is still up for grabs
+		if room.GameState.IsActive && room.GameState.CurrentWord != "" && canGuess(room, client.ID) &&
+			!room.GameState.PlayersGuessed[client.ID] {
+			recordWrongGuess(client)
+			sendGuessResult(client, chatMsg, wrongGuessResult(room, chatMsg))
+		}
+
+		// Muted players can still guess, but nobody sees their chat
+		if client.Muted {
+			sendChatMessage(client, systemMessage(room, "youAreMuted"))
+			sendEvent(room, client, "messageBlocked", map[string]interface{}{
+				"reason": "muted",
+			})
+			return
+		}
+
+		// Players who guessed the word could give it away, so they only
+		// chat with others who know it
+		if room.GameState.IsActive && room.GameState.PlayersGuessed[client.ID] {
+			sendToGuessed(room, ChatMessage{
+				Username: client.Username,
+				Message:  chatMsg,
+				Channel:  "guessed",
+			})
+			return
 		}
 
 		// Broadcast regular chat message
@@ -208,19 +520,122 @@ func handleMessage(client *Client, message Message) {
 		})
 
 	case "startGame":
-		// Only owner can start the game and need at least 2 players
-		if client.Type == "owner" && !room.GameState.IsActive && len(room.Clients) >= 2 {
+		// Only owner can start the game and need at least 2 players,
+		// spectators don't count
+		if client.Type == "owner" && !room.GameState.IsActive && countPlayers(room) >= 2 {
+			// Starting by hand makes a pending auto-start redundant
+			if room.AutoStartTimer != nil {
+				room.AutoStartTimer.Stop()
+				room.AutoStartTimer = nil
+			}
 			startNewRound(room)
 		} else {
-			if len(room.Clients) < 2 {
-				broadcastChatMessage(room, ChatMessage{
-					Username: "System",
-					Message:  "Need at least 2 players to start the game!",
-					IsSystem: true,
-				})
+			if countPlayers(room) < 2 {
+				broadcastChatMessage(room, systemMessage(room, "needPlayers"))
 			}
 		}
 
+	case "usePowerup":
+		data, ok := message.Data.(map[string]interface{})
+		if !ok {
+			return
+		}
+
+		usePowerup(room, client, data)
+
+	case "categoryVote":
+		data, ok := message.Data.(map[string]interface{})
+		if !ok {
+			return
+		}
+
+		voteCategory(room, client, data)
+
+	case "pollVote":
+		data, ok := message.Data.(map[string]interface{})
+		if !ok {
+			return
+		}
+
+		votePoll(room, client, data)
+
+	case "suggestWord":
+		data, ok := message.Data.(map[string]interface{})
+		if !ok {
+			return
+		}
+
+		suggestWord(room, client, data)
+
+	case "cancelAutoStart":
+		if client.Type == "owner" {
+			cancelAutoStart(room)
+		}
+
+	case "closeRoom":
+		if client.Type == "owner" {
+			closeRoom(room)
+		}
+
+	case "nextRound":
+		// With manual turns the owner moves the game on once everyone's ready
+		if client.Type == "owner" && room.GameState.WaitingForOwner && countPlayers(room) >= 2 {
+			startNewRound(room)
+		}
+
+	case "endGame":
+		if client.Type == "owner" && (room.GameState.IsActive || room.GameState.WaitingForOwner) {
+			broadcastChatMessage(room, systemMessage(room, "gameEnded"))
+			broadcastEvent(room, "gameEnded", map[string]interface{}{
+				"reason": "owner",
+			})
+			stopGame(room)
+		}
+
+	case "balanceTeams":
+		if client.Type != "owner" {
+			return
+		}
+		data, _ := message.Data.(map[string]interface{})
+		rebalanceTeams(room, data)
+
+	case "setDrawer":
+		if client.Type != "owner" {
+			return
+		}
+		data, _ := message.Data.(map[string]interface{})
+		setFixedDrawer(room, data)
+
+	case "setProfile":
+		data, ok := message.Data.(map[string]interface{})
+		if !ok {
+			return
+		}
+
+		setProfile(room, client, data)
+
+	case "setWebhook":
+		if client.Type != "owner" {
+			return
+		}
+		data, _ := message.Data.(map[string]interface{})
+		setRoomWebhook(room, client, data["url"])
+
+	case "updateSettings":
+		// Only owner can change settings, and not in the middle of a game
+		if client.Type != "owner" || room.GameState.IsActive {
+			return
+		}
+
+		data, ok := message.Data.(map[string]interface{})
+		if !ok {
+			return
+		}
+
+		updateSettings(room, data)
+		broadcastSettings(room)
+		checkAutoStart(room)
+
 	case "chooseWord":
 		// Current drawer chooses word
 		if client.ID == room.GameState.CurrentDrawer && len(room.GameState.WordChoices) > 0 {
@@ -234,157 +649,293 @@ func handleMessage(client *Client, message Message) {
 				return
 			}
 
-			room.GameState.CurrentWord = room.GameState.WordChoices[int(wordIndex)]
+			choices := room.GameState.WordChoices
+			room.GameState.CurrentWord = choices[int(wordIndex)]
 			room.GameState.WordChoices = nil
-			room.GameState.WordHint = generateHint(room.GameState.CurrentWord)
-			room.RoundStartTime = time.Now()
+			room.GameState.Phase = PhaseDrawing
+			room.GameState.WordHint = generateHint(room.GameState.CurrentWord, room.GameState.HintPolicy)
+			if room.Settings.AnnounceCategory {
+				room.GameState.Category = wordCategory(room, room.GameState.CurrentWord)
+			}
+			if room.Settings.BonusWord {
+				room.GameState.CurrentBonusWord = getBonusWord(room, room.GameState.CurrentWord)
+			}
+			recentWords.Add(room.Settings.Language, room.GameState.CurrentWord)
+			recentWords.Add(room.Settings.Language, room.GameState.CurrentBonusWord)
+			openPoll(room)
+			startRoundClock(room)
+			markDrawerActive(room)
+			client.Stats.TurnsDrawn++
 
 			broadcastGameState(room)
-			broadcastChatMessage(room, ChatMessage{
-				Username: "System",
-				Message:  client.Username + " is now drawing!",
-				IsSystem: true,
+			broadcastChatMessage(room, systemMessage(room, "nowDrawing", client.Username))
+			broadcastEvent(room, "wordChosen", map[string]interface{}{
+				"drawerId": client.ID,
+			})
+			recordReplay(room, "wordChosen", map[string]interface{}{
+				"word":      room.GameState.CurrentWord,
+				"bonusWord": room.GameState.CurrentBonusWord,
+			})
+			trackEvent(room, "word_chosen", map[string]interface{}{
+				"word":     room.GameState.CurrentWord,
+				"choices":  choices,
+				"drawerId": client.ID,
 			})
 
 			// Start round timer
-			go roundTimer(room)
+			state := room.GameState
+			goRoom(room, "round timer", func() {
+				roundTimer(room, state)
+			})
 		}
 	}
 }
 
 func startNewRound(room *Room) {
+	// A pause for a drawer who never came back ends with their turn
+	room.DrawerLeftAt = time.Time{}
 
-	// if 10 rounds have been played, reset scores and send results
-	if room.GameState != nil && room.GameState.RoundNumber >= 10 {
-		// Send final results
-		results := []Player{}
-		for _, c := range room.Clients {
-			results = append(results, Player{
-				ID:       c.ID,
-				Username: c.Username,
-				Type:     c.Type,
-				Score:    c.Score,
-			})
-		}
-		broadcastChatMessage(room, ChatMessage{
-			Username: "System",
-			Message:  "Final Results!",
-			IsSystem: true,
-		})
-
-		resultMessage := Message{
-			Type: "results",
-			Data: results,
-		}
-		jsonData, _ := json.Marshal(resultMessage)
-		for _, client := range room.Clients {
-			client.Conn.WriteMessage(websocket.TextMessage, jsonData)
-		}
-		// Reset scores
-		for _, c := range room.Clients {
-			c.Score = 0
-		}
-		room.GameState.RoundNumber = 0
-		room.GameState.PlayersGuessed = make(map[string]bool)
-	}
+	// Skip votes only ever apply to the round they were cast in
+	room.SkipVotes = make(map[string]bool)
+	resetDrawStrikes(room)
 
-	// Get next drawer
-	var drawerID string
-	clientIDs := make([]string, 0, len(room.Clients))
-	for id := range room.Clients {
-		clientIDs = append(clientIDs, id)
+	if countPlayers(room) == 0 {
+		return
 	}
 
-	if len(clientIDs) == 0 {
+	// A room that has played past the cap gets its results and goes back
+	// to the lobby instead of another turn
+	if room.GameState.Phase == PhaseLobby {
+		room.PlayingSince = time.Now()
+	} else if gameOverdue(room) {
+		concludeOverdueGame(room)
 		return
 	}
 
-	// Find current drawer index
-	currentIndex := -1
-	for i, id := range clientIDs {
-		if id == room.CurrentDrawer {
-			currentIndex = i
-			break
+	// The intermission's category vote decides where this turn's words
+	// come from
+	closeCategoryVote(room)
+
+	// Once every round has been played, settle any tie for first place and
+	// then reset scores and send results
+	round := room.Round
+	drawerID, ok := nextTurn(room)
+	if !ok {
+		if needsTiebreaker(room) {
+			startTiebreakerRound(room)
+			return
 		}
-	}
 
-	// Get next drawer
-	nextIndex := (currentIndex + 1) % len(clientIDs)
-	drawerID = clientIDs[nextIndex]
+		sendFinalResults(room)
+		drawerID, _ = nextTurn(room)
+	}
 	room.CurrentDrawer = drawerID
 
-	// Generate word choices
-	wordChoices := getRandomWords(5)
+	if room.Settings.Mode == ModeMarathon && room.Round != round && round > 0 && round%leaderboardInterval == 0 {
+		broadcastLeaderboard(room, round)
+	}
+	if totalRounds(room) >= 2 && room.Round != round && round == totalRounds(room)/2 {
+		broadcastHalftime(room, round)
+	}
 
-	// Preserve round number or start at 1
-	currentRound := 0
-	if room.GameState != nil {
-		currentRound = room.GameState.RoundNumber
+	// Each game draws from a seed of its own, recorded in its replay.
+	// Teams are made up as it starts
+	if room.Round == 1 && room.Turn == 1 {
+		seedRoom(room, gameSeed(room))
+		balanceTeams(room, room.Settings.TeamBalance)
 	}
 
+	// Generate word choices
+	wordChoices := getRandomWords(room, wordChoiceCount(room))
+
 	room.GameState = &GameState{
 		IsActive:       true,
+		Phase:          PhaseChoosing,
 		CurrentDrawer:  drawerID,
-		TimeRemaining:  80,
-		RoundNumber:    currentRound + 1,
+		TimeRemaining:  room.Settings.RoundTime,
+		RoundTime:      room.Settings.RoundTime,
+		RoundNumber:    room.Round,
+		TotalRounds:    totalRounds(room),
+		TurnNumber:     room.Turn,
+		TurnsInRound:   turnsInRound(room),
+		UpNext:         upcomingDrawers(room),
 		WordChoices:    wordChoices,
+		HintPolicy:     room.Settings.HintPolicy,
+		CanvasWidth:    room.Settings.CanvasWidth,
+		CanvasHeight:   room.Settings.CanvasHeight,
 		PlayersGuessed: make(map[string]bool),
 	}
 
 	broadcastGameState(room)
 	broadcastPlayers(room)
 
+	if room.Round == 1 && room.Turn == 1 {
+		startReplay(room)
+		gamesPlayed.Add()
+		trackEvent(room, "game_started", map[string]interface{}{
+			"players":     countPlayers(room),
+			"totalRounds": totalRounds(room),
+			"roundTime":   room.Settings.RoundTime,
+			"hintPolicy":  room.Settings.HintPolicy,
+			"mode":        room.Settings.Mode,
+		})
+	}
+
+	recordTurnStart(room)
+
 	// Clear canvas for all players at start of new round
+	clearCanvas(room)
+
+	if room.Settings.Mode == ModeMarathon {
+		broadcastChatMessage(room, systemMessage(room, "newMarathonTurn",
+			room.GameState.RoundNumber, room.GameState.TurnNumber, room.GameState.TurnsInRound))
+	} else {
+		broadcastChatMessage(room, systemMessage(room, "newTurn",
+			room.GameState.RoundNumber, room.GameState.TotalRounds, room.GameState.TurnNumber, room.GameState.TurnsInRound))
+	}
+	if room.Turn == 1 {
+		broadcastEvent(room, "roundStarted", map[string]interface{}{
+			"roundNumber": room.GameState.RoundNumber,
+			"totalRounds": room.GameState.TotalRounds,
+		})
+	}
+	broadcastEvent(room, "turnStarted", map[string]interface{}{
+		"roundNumber":  room.GameState.RoundNumber,
+		"turnNumber":   room.GameState.TurnNumber,
+		"turnsInRound": room.GameState.TurnsInRound,
+		"drawerId":     room.GameState.CurrentDrawer,
+	})
+
+}
+
+func sendFinalResults(room *Room) {
+	// mutex is already locked by caller function
+	results := standings(room)
+	broadcastChatMessage(room, systemMessage(room, "finalResults"))
+
+	resultMessage := Message{
+		Type: "results",
+		Data: results,
+	}
+	jsonData, _ := json.Marshal(resultMessage)
+	writeToAll(room, jsonData)
+	replay := finishReplay(room, results)
+	sendResultsWebhooks(room, results, replay)
+	recordGameHistory(room, results, replay)
+	recordLeaderboards(room, results)
+
+	if room.Settings.Teams > 0 && room.Teams != nil {
+		broadcastMessage(room, Message{
+			Type: "teams",
+			Data: teamStandings(room),
+		})
+	}
+
+	// Reset scores, streaks, stats and unused power-ups
+	room.LastScores = room.Scores.Snapshot()
+	room.Scores.Reset()
+	for _, c := range room.Clients {
+		c.Streak = 0
+		c.Powerups = nil
+		c.Stats = PlayerStats{}
+	}
+	room.GameState.RoundNumber = 0
+	room.GameState.PlayersGuessed = make(map[string]bool)
+	resetTurns(room)
+	room.TiebreakerPlayers = nil
+	room.TiebreakerTurns = 0
+}
+
+func clearCanvas(room *Room) {
+	// The wipe starts the new turn's stroke log, so it's on record who
+	// cleared the canvas last
+	wipe := Clear{Type: "clear", By: clearByServer}
+	room.StrokeLog = []DrawOp{wipe}
+	room.Keyframe = nil
+	room.KeyframeOps = len(room.StrokeLog)
+	room.Background = room.Settings.Background
+
+	// Clear canvas for all players
 	clearMessage := Message{
 		Type: "draw",
-		Data: map[string]interface{}{
-			"type": "clear",
-		},
+		Data: wipe,
 	}
 	jsonData, _ := json.Marshal(clearMessage)
-	for _, client := range room.Clients {
-		client.Conn.WriteMessage(websocket.TextMessage, jsonData)
-	}
-
-	broadcastChatMessage(room, ChatMessage{
-		Username: "System",
-		Message:  "New round started! Waiting for drawer to choose a word...",
-		IsSystem: true,
-	})
+	writeToAll(room, jsonData)
 
+	// Lay the round's background template back down
+	broadcastMessage(room, canvasMessage(room))
 }
 
-func roundTimer(room *Room) {
+func roundTimer(room *Room, state *GameState) {
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
 	for range ticker.C {
-		room.mu.Lock()
-
-		if !room.GameState.IsActive || len(room.GameState.WordChoices) > 0 {
-			room.mu.Unlock()
+		running, timeUp := roundTick(room, state)
+		if timeUp {
+			endRound(room, state)
+		}
+		if !running {
 			return
 		}
+	}
+}
 
-		elapsed := int(time.Since(room.RoundStartTime).Seconds())
-		remaining := 80 - elapsed
+// roundTick counts the round clock down by a tick. It reports whether the
+// timer should keep running, and whether the time just ran out
+func roundTick(room *Room, state *GameState) (bool, bool) {
+	room.mu.Lock()
+	defer room.mu.Unlock()
 
-		if remaining <= 0 {
-			// Time's up!
-			broadcastChatMessage(room, ChatMessage{
-				Username: "System",
-				Message:  "Time's up!",
-				IsSystem: true,
-			})
-			room.mu.Unlock()
-			endRound(room)
-			return
-		}
+	// Stop once the turn is over or the game was reset
+	if room.GameState != state || !room.GameState.IsActive || len(room.GameState.WordChoices) > 0 {
+		return false, false
+	}
 
-		room.GameState.TimeRemaining = remaining
-		broadcastGameState(room)
-		room.mu.Unlock()
+	// The clock stops while the drawer is reconnecting
+	if !room.DrawerLeftAt.IsZero() {
+		return true, false
+	}
+
+	elapsed := int(time.Since(room.RoundStartTime).Seconds())
+	remaining := roundRemaining(room)
+
+	if remaining <= 0 {
+		// Time's up!
+		broadcastChatMessage(room, systemMessage(room, "timesUp"))
+		broadcastEvent(room, "timeUp", nil)
+		return false, true
+	}
+
+	if checkDrawerIdle(room) {
+		return false, true
+	}
+
+	// A poll result and the state update go out as one tick
+	batched := beginBatch(room)
+	if batched {
+		defer flushBatch(room)
 	}
+
+	// Timed hints uncover a letter every so often, but never the whole word
+	if room.GameState.HintPolicy == HintTimed && elapsed/hintInterval(room) > room.GameState.HintsRevealed &&
+		countHidden(room.GameState.WordHint) > 1 {
+		room.GameState.WordHint = revealLetter(room, room.GameState.WordHint, room.GameState.CurrentWord)
+		room.GameState.HintsRevealed++
+	}
+
+	checkAutoHint(room, elapsed)
+
+	// Reveal the spectator poll halfway through the round
+	if room.Poll != nil && remaining <= room.GameState.RoundTime/2 {
+		closePoll(room)
+	}
+
+	room.GameState.TimeRemaining = remaining
+	room.GameState.RoundEndsAt = roundEndsAt(room)
+	broadcastGameState(room)
+	return true, false
 }
 
 func addClientToRoom(room *Room, client *Client) {
@@ -392,53 +943,88 @@ func addClientToRoom(room *Room, client *Client) {
 	room.Clients[client.ID] = client
 }
 
+func hasOwner(room *Room) bool {
+	// mutex is already locked by caller function
+	for _, c := range room.Clients {
+		if c.Type == "owner" {
+			return true
+		}
+	}
+	return false
+}
+
 func removeClientFromRoom(room *Room, clientID string) {
 
 	// if player is owner and there are other players, assign new owner
 	if room.Clients[clientID].Type == "owner" && len(room.Clients) > 1 {
 		for id, c := range room.Clients {
-			if id != clientID {
+			if id != clientID && c.Type != "spectator" {
 				c.Type = "owner"
+				broadcastChatMessage(room, systemMessage(room, "newOwner", c.Username))
+				broadcastEvent(room, "ownerChanged", map[string]interface{}{
+					"playerId": c.ID,
+					"username": c.Username,
+				})
 				break
 			}
 		}
 	}
 
 	delete(room.Clients, clientID)
-}
+	delete(room.KickVotes, clientID)
 
-func broadcastPlayers(room *Room) {
-	// Build players list
-	players := []Player{}
-	for _, client := range room.Clients {
-		players = append(players, Player{
-			ID:        client.ID,
-			Username:  client.Username,
-			Type:      client.Type,
-			Score:     client.Score,
-			IsDrawing: room.GameState.IsActive && client.ID == room.GameState.CurrentDrawer,
-		})
+	// An empty room can be created again, so the old secret must not keep
+	// managing it
+	if len(room.Clients) == 0 {
+		room.OwnerSecret = ""
 	}
+}
 
+func broadcastPlayers(room *Room) {
 	// Create message
 	message := Message{
 		Type: "players",
-		Data: players,
+		Data: playerList(room),
 	}
 
 	// Marshal to JSON
 	jsonData, err := json.Marshal(message)
 	if err != nil {
+		captureError("marshal", err, map[string]interface{}{
+			"type": message.Type,
+		})
 		return
 	}
 
 	// Broadcast to all clients
+	writeToAll(room, jsonData)
+}
+
+// playerList builds the players list as clients get it, ranked
+func playerList(room *Room) []Player {
+	// mutex is already locked by caller function
+	players := []Player{}
 	for _, client := range room.Clients {
-		err := client.Conn.WriteMessage(websocket.TextMessage, jsonData)
-		if err != nil {
-			continue
-		}
+		players = append(players, withStats(Player{
+			ID:        client.ID,
+			Username:  client.Username,
+			Avatar:    client.Avatar,
+			Type:      client.Type,
+			Team:      room.Teams[client.ID],
+			Score:     room.Scores.Score(client.ID),
+			IsDrawing: room.GameState.IsActive && client.ID == room.GameState.CurrentDrawer,
+			Streak:    client.Streak,
+			Powerups:  client.Powerups,
+			Latency:   int(client.Latency.Milliseconds()),
+
+			PendingTurn: hasPendingTurn(room, client.ID),
+		}, client.Stats))
 	}
+	if audience, ok := twitchPlayer(room); ok {
+		players = append(players, audience)
+	}
+	rankPlayers(room, players)
+	return players
 
 }
 
@@ -448,65 +1034,95 @@ func broadcastGameState(room *Room) {
 		return
 	}
 
-	for _, client := range room.Clients {
-		// Create a copy of game state (dereference to copy the struct)
-		stateCopy := *room.GameState
+	// Everyone gets the same public state, marshaled once
+	update, full := gameStateUpdate(room)
+	if full == nil {
+		return
+	}
 
-		// If this client is the drawer, show them the full word
-		if client.ID == room.GameState.CurrentDrawer {
-			stateCopy.WordHint = room.GameState.CurrentWord
-		}
+	// The drawer's copy is only built when the clock is hidden from them
+	var drawerUpdate, drawerFull []byte
+	if room.Settings.HideDrawerTimer {
+		drawerUpdate, drawerFull = withoutTimer(update, room.LastState), withoutTimer(full, room.LastState)
 
-		message := Message{
-			Type: "gameState",
-			Data: &stateCopy,
+		// An empty delta keeps the drawer's sequence numbers from skipping
+		// when only the clock changed
+		if drawerUpdate == nil && update != nil {
+			drawerUpdate = []byte(`{"type":"gameStateDelta","data":{}}`)
 		}
+	}
 
-		jsonData, err := json.Marshal(message)
-		if err != nil {
-			continue
+	// One sequence number covers the broadcast, whichever copy a client
+	// gets. Full snapshots alone don't move it on
+	seq := room.Seq.Load()
+	if update != nil {
+		seq = room.Seq.Add(1)
+	}
+	update, full = withSeq(update, seq), withSeq(full, seq)
+	drawerUpdate, drawerFull = withSeq(drawerUpdate, seq), withSeq(drawerFull, seq)
+
+	fanOut(roomClients(room, ""), func(client *Client) {
+		view := buildClientView(room, client)
+		update, full := update, full
+		if view.HideTimer {
+			update, full = drawerUpdate, drawerFull
 		}
 
-		err = client.Conn.WriteMessage(websocket.TextMessage, jsonData)
-		if err != nil {
-			continue
+		if client.NeedsFullState {
+			if writeToClient(client, full) == nil {
+				client.NeedsFullState = false
+			}
+		} else if update != nil {
+			writeToClient(client, update)
 		}
-	}
+
+		// The word, or an improved hint, only goes to whoever may see it
+		sendPrivateView(client, view)
+	})
 }
 
 func sendGameState(client *Client) {
 	room.mu.RLock()
 	defer room.mu.RUnlock()
 
-	// Create a copy of game state
-	stateCopy := room.GameState
-
 	// Check if game state exists
 	if room.GameState == nil {
 		return
 	}
 
-	// If this client is the drawer, show them the full word
-	if client.ID == room.GameState.CurrentDrawer {
-		stateCopy.WordHint = room.GameState.CurrentWord
-	}
+	stateCopy := publicGameState(room)
+	view := buildClientView(room, client)
 
 	message := Message{
 		Type: "gameState",
-		Data: stateCopy,
+		Data: &stateCopy,
 	}
 
 	jsonData, err := json.Marshal(message)
 	if err != nil {
+		captureError("marshal", err, map[string]interface{}{
+			"type": message.Type,
+		})
 		return
 	}
 
-	err = client.Conn.WriteMessage(websocket.TextMessage, jsonData)
+	if view.HideTimer {
+		jsonData = withoutTimer(jsonData, room.LastState)
+	}
+
+	err = writeToClient(client, jsonData)
 	if err != nil {
 
 		return
 
 	}
+
+	// The next delta builds on the last broadcast, which this client
+	// never saw, so it gets a full snapshot instead
+	client.NeedsFullState = true
+
+	client.PrivateView = nil
+	sendPrivateView(client, view)
 }
 
 func broadcastChatMessage(room *Room, chatMsg ChatMessage) {
@@ -517,32 +1133,68 @@ func broadcastChatMessage(room *Room, chatMsg ChatMessage) {
 
 	jsonData, err := json.Marshal(message)
 	if err != nil {
+		captureError("marshal", err, map[string]interface{}{
+			"type": message.Type,
+		})
 		return
 	}
 
-	for _, client := range room.Clients {
-		err := client.Conn.WriteMessage(websocket.TextMessage, jsonData)
-		if err != nil {
-			continue
-		}
+	writeToAll(room, jsonData)
+	rememberChat(room, chatMsg)
+	mirrorToDiscord(chatMsg)
+}
+
+func broadcastMessage(room *Room, message Message) {
+	jsonData, err := json.Marshal(message)
+	if err != nil {
+		captureError("marshal", err, map[string]interface{}{
+			"type": message.Type,
+		})
+		return
+	}
+
+	writeToAll(room, jsonData)
+}
+
+func sendMessage(client *Client, message Message) {
+	jsonData, err := json.Marshal(message)
+	if err != nil {
+		captureError("marshal", err, map[string]interface{}{
+			"type": message.Type,
+		})
+		return
 	}
+
+	writeToClient(client, jsonData)
 }
 
-func broadcastToOthers(room *Room, senderID string, message Message) {
+func sendChatMessage(client *Client, chatMsg ChatMessage) {
+	message := Message{
+		Type: "chat",
+		Data: chatMsg,
+	}
+
 	jsonData, err := json.Marshal(message)
 	if err != nil {
+		captureError("marshal", err, map[string]interface{}{
+			"type": message.Type,
+		})
 		return
 	}
 
-	for _, client := range room.Clients {
-		if client.ID != senderID {
-			err := client.Conn.WriteMessage(websocket.TextMessage, jsonData)
-			if err != nil {
-				continue
-			}
-		}
+	writeToClient(client, jsonData)
+}
+
+func broadcastToOthers(room *Room, senderID string, message Message) {
+	jsonData, err := json.Marshal(message)
+	if err != nil {
+		captureError("marshal", err, map[string]interface{}{
+			"type": message.Type,
+		})
+		return
 	}
 
+	writeToOthers(room, senderID, jsonData)
 }
 
 func setupRouter() *gin.Engine {
@@ -550,7 +1202,18 @@ func setupRouter() *gin.Engine {
 
 	router := gin.New()
 
-	router.Use(gin.LoggerWithWriter(os.Stdout))
+	// Without trusted proxies ClientIP is the peer address, so clients
+	// can't dodge the rate limits with a made-up X-Forwarded-For
+	if err := router.SetTrustedProxies(trustedProxies()); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+
+	router.Use(requestID())
+
+	router.Use(gin.LoggerWithConfig(gin.LoggerConfig{
+		Formatter: requestLogFormat,
+		Output:    os.Stdout,
+	}))
 
 	router.Use(gin.Recovery())
 
@@ -563,8 +1226,46 @@ func setupRouter() *gin.Engine {
 		MaxAge:           12 * time.Hour,
 	}))
 
-	// WebSocket route
-	router.GET("/ws", wsHandler)
+	// WebSocket route, joining is also how a room is opened
+	router.GET("/ws", rateLimit(), wsHandler)
+
+	// Lobby listing, and other instances reporting their rooms to it
+	router.GET("/rooms", roomsHandler)
+
+	// Finished games for replay viewers
+	router.GET("/rooms/:code/replays", replaysHandler)
+	router.GET("/rooms/:code/replays/:file", replayHandler)
+
+	// Final drawings of finished turns, for sharing
+	router.GET("/rooms/:code/drawings", drawingsHandler)
+	router.GET("/rooms/:code/drawings/:file", drawingHandler)
+	router.POST("/lobby/register", tokenAuth(os.Getenv("FEDERATION_TOKEN")), registerHandler)
+
+	// Settings bundles to create a room from
+	router.GET("/presets", presetsHandler)
+
+	// Running and past seasons of the daily, weekly and monthly leaderboards
+	router.GET("/leaderboards/:period", leaderboardHandler)
+	router.GET("/leaderboards/:period/seasons", seasonsHandler)
+
+	// Player profiles, edited by their owner with their guest token
+	router.GET("/players/:id/profile", profileHandler)
+	router.GET("/players/:id/games", playerGamesHandler)
+	router.GET("/me/profile", guestAuth(), ownProfileHandler)
+	router.PUT("/me/profile", guestAuth(), updateOwnProfileHandler)
+
+	// Public numbers for the homepage
+	router.GET("/stats", statsHandler)
+
+	// Players export or delete their own data with their reconnect token
+	router.GET("/me/data", exportOwnDataHandler)
+	router.DELETE("/me/data", deleteOwnDataHandler)
+
+	// Room management for whoever created it over REST
+	setupRoomRoutes(router)
+
+	// Word curation for admins
+	setupAdminRoutes(router)
 
 	// health check route
 	router.GET("/health", func(c *gin.Context) {
@@ -577,14 +1278,30 @@ func setupRouter() *gin.Engine {
 }
 
 func main() {
-	// Initialize random seed
-	rand.Seed(time.Now().UnixNano())
+	seedRoom(room, initialRoomSeed())
 
 	log.Println("🚀 Starting server on port 42069")
 
-	router := setupRouter()
+	// Let clients animate score changes as they happen
+	room.Scores.OnChange = func(event ScoreEvent) {
+		broadcastMessage(room, Message{
+			Type: "scoreChange",
+			Data: event,
+		})
+		checkTargetScore(room, event)
+	}
+
+	server = &http.Server{
+		Addr:    ":42069",
+		Handler: setupRouter(),
+	}
+	startRegistration()
+	startTwitchChat()
+	startConsole()
 
-	if err := router.Run(":42069"); err != nil {
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
 		log.Fatal("Failed to start server:", err)
 	}
+	<-stopped
+	log.Println("👋 Server stopped")
 }
@@ -0,0 +1,236 @@
+package main
+
+import "encoding/json"
+
+func defaultSettings() RoomSettings {
+	return RoomSettings{
+		HintPolicy:     HintFirstLast,
+		CanvasWidth:    defaultCanvasWidth,
+		CanvasHeight:   defaultCanvasHeight,
+		RoundTime:      defaultRoundTime,
+		DrawerIdleTime: defaultDrawerIdleTime,
+		Intermission:   defaultIntermission,
+		AutoHintAt:     defaultAutoHintAt,
+		ShortenTo:      defaultShortenTo,
+		Rounds:         defaultRounds,
+		Mode:           ModeClassic,
+		TeamBalance:    BalanceRating,
+		Scoring:        ScoringFlat,
+		Language:       defaultLanguage,
+	}
+}
+
+const (
+	defaultRoundTime = 80
+	minRoundTime     = 15
+	maxRoundTime     = 300
+
+	defaultIntermission = 5 // Seconds the word is revealed between turns
+	minIntermission     = 2
+	maxIntermission     = 30
+
+	minWordLength = 2
+	maxWordLength = 30
+
+	minTargetScore = 100
+	maxTargetScore = 100000
+)
+
+const (
+	ModeClassic   = "classic"   // Rounds play out as set up
+	ModeSpeed     = "speed"     // One word to draw, fast hints and a bigger reward for guessing quickly
+	ModeMarathon  = "marathon"  // No round limit, play goes on until the owner ends it or someone reaches the target score
+	ModeClassroom = "classroom" // The same player draws every round for everyone else to guess
+)
+
+func isValidMode(mode string) bool {
+	switch mode {
+	case ModeClassic, ModeSpeed, ModeMarathon, ModeClassroom:
+		return true
+	}
+	return false
+}
+
+// updateSettings applies the fields present in an updateSettings message
+func updateSettings(room *Room, data map[string]interface{}) {
+	// mutex is already locked by caller function
+	// A preset goes first, so fields given next to it override its values
+	if name, ok := data["preset"].(string); ok {
+		if preset, ok := findPreset(name); ok {
+			updateSettings(room, preset.Settings)
+		}
+	}
+
+	if bonusWord, ok := data["bonusWord"].(bool); ok {
+		room.Settings.BonusWord = bonusWord
+	}
+
+	if hintPolicy, ok := data["hintPolicy"].(string); ok && isValidHintPolicy(hintPolicy) {
+		room.Settings.HintPolicy = hintPolicy
+	}
+
+	width, okWidth := data["canvasWidth"].(float64)
+	height, okHeight := data["canvasHeight"].(float64)
+	if okWidth && okHeight && width >= minCanvasSize && width <= maxCanvasSize &&
+		height >= minCanvasSize && height <= maxCanvasSize {
+		room.Settings.CanvasWidth = int(width)
+		room.Settings.CanvasHeight = int(height)
+
+		// Lobby clients should size their canvas right away
+		room.GameState.CanvasWidth = room.Settings.CanvasWidth
+		room.GameState.CanvasHeight = room.Settings.CanvasHeight
+	}
+
+	if background, ok := data["background"].(string); ok && isValidBackground(background) {
+		room.Settings.Background = background
+	}
+
+	if players, ok := data["autoStartPlayers"].(float64); ok && (players == 0 || (players >= 2 && players <= maxAutoStartPlayers)) {
+		room.Settings.AutoStartPlayers = int(players)
+	}
+
+	if roundTime, ok := data["roundTime"].(float64); ok && roundTime >= minRoundTime && roundTime <= maxRoundTime {
+		room.Settings.RoundTime = int(roundTime)
+	}
+
+	if autoHintAt, ok := data["autoHintAt"].(float64); ok && (autoHintAt == 0 || (autoHintAt >= minAutoHintAt && autoHintAt <= maxAutoHintAt)) {
+		room.Settings.AutoHintAt = int(autoHintAt)
+	}
+
+	if shortenTo, ok := data["shortenTo"].(float64); ok && (shortenTo == 0 || (shortenTo >= minShortenTo && shortenTo <= maxShortenTo)) {
+		room.Settings.ShortenTo = int(shortenTo)
+	}
+
+	if intermission, ok := data["intermission"].(float64); ok && intermission >= minIntermission && intermission <= maxIntermission {
+		room.Settings.Intermission = int(intermission)
+	}
+
+	if idle, ok := data["drawerIdleTime"].(float64); ok && (idle == 0 || (idle >= minDrawerIdleTime && idle <= maxDrawerIdleTime)) {
+		room.Settings.DrawerIdleTime = int(idle)
+	}
+
+	if rounds, ok := data["rounds"].(float64); ok && rounds >= minRounds && rounds <= maxRounds {
+		room.Settings.Rounds = int(rounds)
+	}
+
+	if mode, ok := data["mode"].(string); ok && isValidMode(mode) {
+		room.Settings.Mode = mode
+	}
+
+	if teams, ok := data["teams"].(float64); ok && (teams == 0 || (teams >= minTeams && teams <= maxTeams)) {
+		room.Settings.Teams = int(teams)
+	}
+
+	if balance, ok := data["teamBalance"].(string); ok && isValidTeamBalance(balance) {
+		room.Settings.TeamBalance = balance
+	}
+
+	if target, ok := data["targetScore"].(float64); ok && (target == 0 || (target >= minTargetScore && target <= maxTargetScore)) {
+		room.Settings.TargetScore = int(target)
+	}
+
+	if scoring, ok := data["scoring"].(string); ok && isValidScoring(scoring) {
+		room.Settings.Scoring = scoring
+	}
+
+	if categories, ok := data["wordCategories"].(string); ok {
+		if categories, ok := parseCategories(categories); ok {
+			room.Settings.WordCategories = categories
+		}
+	}
+
+	if announce, ok := data["announceCategory"].(bool); ok {
+		room.Settings.AnnounceCategory = announce
+	}
+
+	if categoryVote, ok := data["categoryVote"].(bool); ok {
+		room.Settings.CategoryVote = categoryVote
+	}
+
+	if textGuard, ok := data["textGuard"].(string); ok && isValidTextGuard(textGuard) {
+		room.Settings.TextGuard = textGuard
+	}
+
+	if twitch, ok := data["twitchGuessing"].(bool); ok && (!twitch || twitchChannel != "") {
+		room.Settings.TwitchGuessing = twitch
+	}
+
+	if manualTurns, ok := data["manualTurns"].(bool); ok {
+		room.Settings.ManualTurns = manualTurns
+	}
+
+	if hideTimer, ok := data["hideDrawerTimer"].(bool); ok {
+		room.Settings.HideDrawerTimer = hideTimer
+	}
+
+	if keepScores, ok := data["keepScores"].(bool); ok {
+		room.Settings.KeepScores = keepScores
+	}
+
+	if containsMatch, ok := data["containsMatch"].(bool); ok {
+		room.Settings.ContainsMatch = containsMatch
+	}
+
+	if language, ok := data["language"].(string); ok && isValidLanguage(language) {
+		room.Settings.Language = language
+	}
+
+	// Both limits are checked together so min never ends up above max
+	minLength, okMin := wordLengthSetting(data["minWordLength"], room.Settings.MinWordLength)
+	maxLength, okMax := wordLengthSetting(data["maxWordLength"], room.Settings.MaxWordLength)
+	if okMin && okMax && (minLength == 0 || maxLength == 0 || minLength <= maxLength) {
+		room.Settings.MinWordLength = minLength
+		room.Settings.MaxWordLength = maxLength
+	}
+}
+
+// wordLengthSetting reads a word length limit from an updateSettings
+// message, keeping the current one if the field is missing
+func wordLengthSetting(value interface{}, current int) (int, bool) {
+	if value == nil {
+		return current, true
+	}
+
+	length, ok := value.(float64)
+	if !ok || (length != 0 && (length < minWordLength || length > maxWordLength)) {
+		return current, false
+	}
+	return int(length), true
+}
+
+func broadcastSettings(room *Room) {
+	message := Message{
+		Type: "settings",
+		Data: room.Settings,
+	}
+
+	jsonData, err := json.Marshal(message)
+	if err != nil {
+		captureError("marshal", err, map[string]interface{}{
+			"type": message.Type,
+		})
+		return
+	}
+
+	writeToAll(room, jsonData)
+}
+
+func sendSettings(client *Client) {
+	room.mu.RLock()
+	defer room.mu.RUnlock()
+
+	message := Message{
+		Type: "settings",
+		Data: room.Settings,
+	}
+
+	jsonData, err := json.Marshal(message)
+	if err != nil {
+		captureError("marshal", err, map[string]interface{}{
+			"type": message.Type,
+		})
+		return
+	}
+
+	writeToClient(client, jsonData)
+}
@@ -0,0 +1,35 @@
+package main
+
+const (
+	defaultWordChoices   = 5
+	speedHintInterval    = 6 // Seconds between letters uncovered by timed hints in speed mode
+	speedTimeBonusFactor = 2
+)
+
+// wordChoiceCount is how many words the drawer picks from. Speed mode
+// drawers get a single one so no time goes to choosing
+func wordChoiceCount(room *Room) int {
+	// mutex is already locked by caller function
+	if room.Settings.Mode == ModeSpeed {
+		return 1
+	}
+	return defaultWordChoices
+}
+
+// hintInterval is the seconds between letters uncovered by timed hints
+func hintInterval(room *Room) int {
+	// mutex is already locked by caller function
+	if room.Settings.Mode == ModeSpeed {
+		return speedHintInterval
+	}
+	return roomVariant(room).HintInterval
+}
+
+// timeBonusFactor multiplies the points a guess earns for time left
+func timeBonusFactor(room *Room) int {
+	// mutex is already locked by caller function
+	if room.Settings.Mode == ModeSpeed {
+		return speedTimeBonusFactor
+	}
+	return 1
+}
@@ -0,0 +1,85 @@
+package main
+
+import "sort"
+
+// PlayerStats are a player's figures for the current game
+type PlayerStats struct {
+	WordsGuessed int
+	GuessTime    int64 // Total milliseconds their correct guesses took
+	TurnsDrawn   int
+	BestStreak   int   // Longest run of words guessed in a row
+	FastestGuess int64 // Milliseconds their quickest correct guess took
+}
+
+func (s PlayerStats) AverageGuessTime() int64 {
+	if s.WordsGuessed == 0 {
+		return 0
+	}
+	return s.GuessTime / int64(s.WordsGuessed)
+}
+
+// withStats fills in a player's game stats for the scoreboard
+func withStats(player Player, stats PlayerStats) Player {
+	player.WordsGuessed = stats.WordsGuessed
+	player.AverageGuessTime = stats.AverageGuessTime()
+	player.TurnsDrawn = stats.TurnsDrawn
+	return player
+}
+
+// standings are the ranked players of the game so far, for results and
+// leaderboards. Spectators are left out, the Twitch audience is in
+func standings(room *Room) []Player {
+	// mutex is already locked by caller function
+	results := []Player{}
+	for _, c := range room.Clients {
+		if c.Type == "spectator" {
+			continue
+		}
+		results = append(results, withStats(Player{
+			ID:       c.ID,
+			Username: c.Username,
+			Avatar:   c.Avatar,
+			Type:     c.Type,
+			Team:     room.Teams[c.ID],
+			Score:    room.Scores.Score(c.ID),
+		}, c.Stats))
+	}
+	if audience, ok := twitchPlayer(room); ok {
+		results = append(results, audience)
+	}
+	rankPlayers(room, results)
+	return results
+}
+
+// rankPlayers sorts players into the standings every client shows: highest
+// score first, ties going to whoever joined the room first. Players get
+// their place as rank, spectators go last without one
+func rankPlayers(room *Room, players []Player) {
+	// mutex is already locked by caller function
+	joinedAt := func(id string) int64 {
+		if c, ok := room.Clients[id]; ok {
+			return c.JoinedAt.UnixNano()
+		}
+		return 0
+	}
+
+	sort.SliceStable(players, func(i, j int) bool {
+		a, b := players[i], players[j]
+		if (a.Type == "spectator") != (b.Type == "spectator") {
+			return b.Type == "spectator"
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if joinedAt(a.ID) != joinedAt(b.ID) {
+			return joinedAt(a.ID) < joinedAt(b.ID)
+		}
+		return a.ID < b.ID
+	})
+
+	for i := range players {
+		if players[i].Type != "spectator" {
+			players[i].Rank = i + 1
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	maxSuggestions      = 500 // Pending suggestions kept for review
+	minSuggestionLength = 2
+	maxSuggestionLength = 30
+	suggestionInterval  = 5 * time.Second // Between two suggestions of a player
+)
+
+var (
+	errInvalidSuggestion   = errors.New("invalid word")
+	errDuplicateSuggestion = errors.New("word already known")
+	errSuggestionsFull     = errors.New("too many pending suggestions")
+	errUnknownSuggestion   = errors.New("unknown suggestion")
+)
+
+type Suggestion struct {
+	ID          string    `json:"id"`
+	Word        string    `json:"word"`
+	Language    string    `json:"language"`
+	Category    string    `json:"category"`
+	SuggestedBy string    `json:"suggestedBy"`
+	PlayerID    string    `json:"playerId,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// Suggest adds a player's word to the pending pool for an admin to review.
+// It is saved by flushLoop, so a burst of suggestions is one write
+func (s *WordStore) Suggest(suggestion Suggestion) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, words := range s.Packs[suggestion.Language] {
+		if containsWord(words, suggestion.Word) {
+			return errDuplicateSuggestion
+		}
+	}
+	for _, pending := range s.Pending {
+		if pending.Language == suggestion.Language && strings.EqualFold(pending.Word, suggestion.Word) {
+			return errDuplicateSuggestion
+		}
+	}
+
+	if len(s.Pending) >= maxSuggestions {
+		return errSuggestionsFull
+	}
+
+	s.Pending = append(s.Pending, suggestion)
+	s.dirty = true
+	return nil
+}
+
+func (s *WordStore) Suggestions() []Suggestion {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]Suggestion{}, s.Pending...)
+}
+
+// Approve promotes a pending suggestion into the live word list, into the
+// given category or the one it was suggested for
+func (s *WordStore) Approve(id string, category string) (Suggestion, error) {
+	suggestion, err := s.takeSuggestion(id)
+	if err != nil {
+		return suggestion, err
+	}
+
+	if category != "" {
+		suggestion.Category = category
+	}
+	return suggestion, s.Add(suggestion.Language, suggestion.Category, []string{suggestion.Word})
+}
+
+func (s *WordStore) Reject(id string) (Suggestion, error) {
+	suggestion, err := s.takeSuggestion(id)
+	if err != nil {
+		return suggestion, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return suggestion, s.save()
+}
+
+// SuggestionsBy returns the pending suggestions a player made
+func (s *WordStore) SuggestionsBy(playerID string) []Suggestion {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	suggestions := []Suggestion{}
+	for _, suggestion := range s.Pending {
+		if suggestion.PlayerID == playerID {
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+	return suggestions
+}
+
+// DeleteSuggestionsBy drops a player's pending suggestions, saving only
+// when there were any
+func (s *WordStore) DeleteSuggestionsBy(playerID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := []Suggestion{}
+	for _, suggestion := range s.Pending {
+		if suggestion.PlayerID != playerID {
+			kept = append(kept, suggestion)
+		}
+	}
+	if len(kept) == len(s.Pending) {
+		return nil
+	}
+
+	s.Pending = kept
+	return s.save()
+}
+
+// takeSuggestion removes a suggestion from the pending pool
+func (s *WordStore) takeSuggestion(id string) (Suggestion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, suggestion := range s.Pending {
+		if suggestion.ID == id {
+			s.Pending = append(s.Pending[:i], s.Pending[i+1:]...)
+			return suggestion, nil
+		}
+	}
+	return Suggestion{}, errUnknownSuggestion
+}
+
+// isValidSuggestion only accepts words made of letters, with single spaces
+// or hyphens between them
+func isValidSuggestion(word string) bool {
+	length := len([]rune(word))
+	if length < minSuggestionLength || length > maxSuggestionLength {
+		return false
+	}
+
+	for _, char := range word {
+		if !unicode.IsLetter(char) && char != ' ' && char != '-' {
+			return false
+		}
+	}
+	return !strings.Contains(word, "  ")
+}
+
+// suggestWord handles a suggestWord message, the word goes into the pending
+// pool for the room's language. Players get one suggestion every
+// suggestionInterval
+func suggestWord(room *Room, client *Client, data map[string]interface{}) {
+	// mutex is already locked by caller function
+	if time.Since(client.SuggestedAt) < suggestionInterval {
+		sendChatMessage(client, systemMessage(room, "suggestionTooSoon"))
+		return
+	}
+	client.SuggestedAt = time.Now()
+
+	word, _ := data["word"].(string)
+	word = strings.ToLower(strings.TrimSpace(word))
+
+	category, _ := data["category"].(string)
+	category = strings.ToLower(strings.TrimSpace(category))
+	if category == "" {
+		category = defaultCategory
+	}
+
+	err := errInvalidSuggestion
+	if isValidSuggestion(word) && isValidSuggestion(category) {
+		err = wordStore.Suggest(Suggestion{
+			ID:          uuid.New().String(),
+			Word:        word,
+			Language:    room.Settings.Language,
+			Category:    category,
+			SuggestedBy: client.Username,
+			PlayerID:    client.ID,
+			CreatedAt:   time.Now(),
+		})
+	}
+
+	switch err {
+	case nil:
+		clientLogf(client, "💡 %s suggested the word %q\n", client.Username, word)
+		sendChatMessage(client, systemMessage(room, "suggestionReceived", word))
+	case errDuplicateSuggestion:
+		sendChatMessage(client, systemMessage(room, "suggestionDuplicate", word))
+	case errSuggestionsFull:
+		sendChatMessage(client, systemMessage(room, "suggestionsFull"))
+	case errInvalidSuggestion:
+		sendChatMessage(client, systemMessage(room, "suggestionInvalid"))
+	}
+
+	sendEvent(room, client, "wordSuggested", map[string]interface{}{
+		"word":     word,
+		"accepted": err == nil,
+	})
+}
+
+func listSuggestionsHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"suggestions": wordStore.Suggestions(),
+	})
+}
+
+// approveSuggestionHandler promotes a suggestion into the word list, an
+// optional "category" in the body overrides the suggested one
+func approveSuggestionHandler(c *gin.Context) {
+	var request WordsRequest
+	c.ShouldBindJSON(&request)
+
+	suggestion, err := wordStore.Approve(c.Param("id"), request.Category)
+	if !respondSuggestionError(c, err) {
+		return
+	}
+
+	log.Printf("✅ Approved suggested word %q\n", suggestion.Word)
+	c.JSON(http.StatusOK, gin.H{
+		"suggestion": suggestion,
+	})
+}
+
+func rejectSuggestionHandler(c *gin.Context) {
+	suggestion, err := wordStore.Reject(c.Param("id"))
+	if !respondSuggestionError(c, err) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"suggestion": suggestion,
+	})
+}
+
+// respondSuggestionError answers with the error, if there is one, and
+// reports whether the handler can carry on
+func respondSuggestionError(c *gin.Context, err error) bool {
+	switch err {
+	case nil:
+		return true
+	case errUnknownSuggestion:
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+	default:
+		logError(c.GetString("requestId"), "Failed to save words", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to save words",
+		})
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package main
+
+import "log"
+
+// checkTargetScore ends the game as soon as a score change takes someone to
+// the room's target score. Points are awarded in the middle of handling a
+// guess or a power-up, so the game is stopped right after, once that is done
+func checkTargetScore(room *Room, event ScoreEvent) {
+	// mutex is already locked by caller function
+	target := room.Settings.TargetScore
+	if target == 0 || event.Points <= 0 || event.Total < target || !room.GameState.IsActive {
+		return
+	}
+
+	state := room.GameState
+	goRoom(room, "target score", func() {
+		room.mu.Lock()
+		defer room.mu.Unlock()
+
+		// Only the first to get there wins, and only if the game is still on
+		if room.GameState != state {
+			return
+		}
+
+		if beginBatch(room) {
+			defer flushBatch(room)
+		}
+
+		winner := event.PlayerID
+		for _, player := range standings(room) {
+			if player.ID == event.PlayerID {
+				winner = player.Username
+			}
+		}
+
+		log.Printf("🏁 %s reached the target score of %d\n", winner, target)
+		broadcastChatMessage(room, systemMessage(room, "targetScoreReached", winner, target))
+		broadcastEvent(room, "gameEnded", map[string]interface{}{
+			"reason":   "targetScore",
+			"playerId": event.PlayerID,
+		})
+		stopGame(room)
+	})
+}
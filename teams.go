@@ -0,0 +1,161 @@
+package main
+
+import (
+	"log"
+	"sort"
+)
+
+const (
+	minTeams = 2
+	maxTeams = 4
+
+	BalanceRating = "rating" // Spread players by their average points this month
+	BalanceScore  = "score"  // Spread players by their score in the last game
+	BalanceKeep   = "keep"   // Keep the teams as they are, newcomers join the smallest
+)
+
+// Team is one side of a team game. Its score is what its members scored
+type Team struct {
+	Number  int      `json:"number"`
+	Players []string `json:"players"`
+	Score   int      `json:"score"`
+}
+
+func isValidTeamBalance(balance string) bool {
+	switch balance {
+	case BalanceRating, BalanceScore, BalanceKeep:
+		return true
+	}
+	return false
+}
+
+// balanceTeams splits the players into the room's number of teams as a
+// game starts, taking turns picking the strongest player left in snake
+// order so no team stacks the best players
+func balanceTeams(room *Room, balance string) {
+	// mutex is already locked by caller function
+	if room.Settings.Teams == 0 {
+		room.Teams = nil
+		return
+	}
+
+	players := []string{}
+	for id, c := range room.Clients {
+		if c.Type != "spectator" {
+			players = append(players, id)
+		}
+	}
+
+	if balance == BalanceKeep && room.Teams != nil {
+		teams := make(map[string]int)
+		for _, id := range players {
+			if team := room.Teams[id]; team >= 1 && team <= room.Settings.Teams {
+				teams[id] = team
+			}
+		}
+		room.Teams = teams
+		for _, id := range players {
+			joinTeam(room, id)
+		}
+		broadcastTeams(room)
+		return
+	}
+
+	strength := func(id string) float64 {
+		if balance == BalanceScore {
+			return float64(room.LastScores[id])
+		}
+		return leaderboards.Rating(id)
+	}
+
+	// Equally strong players land on teams at random
+	room.Rand.Shuffle(len(players), func(i, j int) {
+		players[i], players[j] = players[j], players[i]
+	})
+	sort.SliceStable(players, func(i, j int) bool {
+		return strength(players[i]) > strength(players[j])
+	})
+
+	count := room.Settings.Teams
+	room.Teams = make(map[string]int)
+	for i, id := range players {
+		pick := i % count
+		if (i/count)%2 == 1 {
+			pick = count - 1 - pick
+		}
+		room.Teams[id] = pick + 1
+	}
+
+	log.Printf("⚖️ Balanced %d players into %d teams by %s\n", len(players), count, balance)
+	broadcastChatMessage(room, systemMessage(room, "teamsBalanced"))
+	broadcastTeams(room)
+}
+
+// joinTeam puts a player without a team on the smallest one
+func joinTeam(room *Room, playerID string) {
+	// mutex is already locked by caller function
+	if room.Settings.Teams == 0 || room.Teams == nil || room.Teams[playerID] != 0 {
+		return
+	}
+
+	sizes := make([]int, room.Settings.Teams+1)
+	for id, team := range room.Teams {
+		if _, ok := room.Clients[id]; ok && team < len(sizes) {
+			sizes[team]++
+		}
+	}
+
+	smallest := 1
+	for team := 2; team <= room.Settings.Teams; team++ {
+		if sizes[team] < sizes[smallest] {
+			smallest = team
+		}
+	}
+	room.Teams[playerID] = smallest
+}
+
+// teamStandings lists the teams with their members in the room and their
+// combined score
+func teamStandings(room *Room) []Team {
+	// mutex is already locked by caller function
+	teams := []Team{}
+	for number := 1; number <= room.Settings.Teams; number++ {
+		teams = append(teams, Team{Number: number, Players: []string{}})
+	}
+
+	for id, team := range room.Teams {
+		c, ok := room.Clients[id]
+		if !ok || c.Type == "spectator" || team < 1 || team > len(teams) {
+			continue
+		}
+		teams[team-1].Players = append(teams[team-1].Players, id)
+		teams[team-1].Score += room.Scores.Score(id)
+	}
+	for _, team := range teams {
+		sort.Strings(team.Players)
+	}
+	return teams
+}
+
+func broadcastTeams(room *Room) {
+	// mutex is already locked by caller function
+	broadcastMessage(room, Message{
+		Type: "teams",
+		Data: teamStandings(room),
+	})
+	broadcastPlayers(room)
+}
+
+// rebalanceTeams lets the owner make up the teams again between games
+func rebalanceTeams(room *Room, data map[string]interface{}) {
+	// mutex is already locked by caller function
+	if room.GameState.IsActive || room.Settings.Teams == 0 {
+		return
+	}
+
+	balance, _ := data["by"].(string)
+	if !isValidTeamBalance(balance) {
+		balance = room.Settings.TeamBalance
+	}
+	balanceTeams(room, balance)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"log"
+	"math"
+	"sort"
+)
+
+const (
+	TextGuardOff   = ""      // Strokes aren't checked for writing
+	TextGuardFlag  = "flag"  // The owner and moderators are told when a drawer seems to be writing
+	TextGuardBlock = "block" // Like flag, and the drawer's turn is skipped
+)
+
+const (
+	smallStrokeShare = 0.08 // Largest side of a letter-sized stroke, as a share of the canvas
+	textWindow       = 12   // Recent small strokes looked at for a line of writing
+	textLineStrokes  = 8    // Small strokes lined up in a row that look like writing
+	textLineSpan     = 3    // How many letter heights wide a line of writing is at least
+	tinyStrokesMin   = 40   // Strokes in a turn before their sizes are judged
+	tinyStrokesShare = 0.75 // Share of small strokes that is too many
+)
+
+// strokeBox is the bounding box of a stroke
+type strokeBox struct {
+	MinX, MinY, MaxX, MaxY float64
+}
+
+func (b strokeBox) height() float64  { return b.MaxY - b.MinY }
+func (b strokeBox) centerY() float64 { return (b.MinY + b.MaxY) / 2 }
+
+func isValidTextGuard(mode string) bool {
+	switch mode {
+	case TextGuardOff, TextGuardFlag, TextGuardBlock:
+		return true
+	}
+	return false
+}
+
+func boundingBox(points []Point) strokeBox {
+	box := strokeBox{MinX: math.Inf(1), MinY: math.Inf(1), MaxX: math.Inf(-1), MaxY: math.Inf(-1)}
+	for _, p := range points {
+		box.MinX = math.Min(box.MinX, p.X)
+		box.MinY = math.Min(box.MinY, p.Y)
+		box.MaxX = math.Max(box.MaxX, p.X)
+		box.MaxY = math.Max(box.MaxY, p.Y)
+	}
+	return box
+}
+
+// checkTextStrokes looks at a drawer's stroke for signs they are writing
+// the word out instead of drawing it: a run of letter-sized strokes lined
+// up in a row, or a turn made of almost nothing but tiny strokes. It is a
+// heuristic, so the first hit in a turn is only reported, unless the room
+// blocks writing. It reports whether the stroke should be dropped
+func checkTextStrokes(room *Room, client *Client, stroke Stroke) bool {
+	// mutex is already locked by caller function
+	state := room.GameState
+	if room.Settings.TextGuard == TextGuardOff || stroke.Type != "stroke" {
+		return false
+	}
+	if state.TextFlagged {
+		return room.Settings.TextGuard == TextGuardBlock
+	}
+
+	box := boundingBox(stroke.Points)
+	limit := smallStrokeShare * float64(min(state.CanvasWidth, state.CanvasHeight))
+
+	state.Strokes++
+	if math.Max(box.MaxX-box.MinX, box.height()) <= limit {
+		state.SmallStrokes = append(state.SmallStrokes, box)
+		if len(state.SmallStrokes) > textWindow {
+			state.SmallStrokes = state.SmallStrokes[1:]
+		}
+		state.SmallStrokeCount++
+	}
+
+	reason := ""
+	switch {
+	case looksLikeWriting(state.SmallStrokes):
+		reason = "letters"
+	case state.Strokes >= tinyStrokesMin && float64(state.SmallStrokeCount) >= tinyStrokesShare*float64(state.Strokes):
+		reason = "tinyStrokes"
+	default:
+		return false
+	}
+
+	state.TextFlagged = true
+	flagWriting(room, client, reason)
+	return room.Settings.TextGuard == TextGuardBlock
+}
+
+// looksLikeWriting checks whether enough of the recent small strokes sit on
+// one line, about as tall as each other and spread out sideways like
+// letters in a word
+func looksLikeWriting(boxes []strokeBox) bool {
+	if len(boxes) < textLineStrokes {
+		return false
+	}
+
+	heights := make([]float64, len(boxes))
+	centers := make([]float64, len(boxes))
+	for i, box := range boxes {
+		heights[i] = box.height()
+		centers[i] = box.centerY()
+	}
+	height := median(heights)
+	line := median(centers)
+	if height == 0 {
+		return false
+	}
+
+	inLine := 0
+	left, right := math.Inf(1), math.Inf(-1)
+	for _, box := range boxes {
+		if math.Abs(box.centerY()-line) > height || box.height() > 2*height {
+			continue
+		}
+		inLine++
+		left = math.Min(left, box.MinX)
+		right = math.Max(right, box.MaxX)
+	}
+
+	return inLine >= textLineStrokes && right-left >= textLineSpan*height
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	return sorted[len(sorted)/2]
+}
+
+// flagWriting reports a drawer who seems to be writing to the room owner
+// and the admin feed, and skips their turn if the room blocks writing
+func flagWriting(room *Room, drawer *Client, reason string) {
+	// mutex is already locked by caller function
+	clientLogf(drawer, "✍️ Drawer %s seems to be writing the word (%s)\n", drawer.Username, reason)
+	adminFeed.Publish("textFlagged", map[string]interface{}{
+		"playerId": drawer.ID,
+		"username": drawer.Username,
+		"word":     room.GameState.CurrentWord,
+		"reason":   reason,
+	})
+
+	for _, c := range room.Clients {
+		if c.Type == "owner" && c.ID != drawer.ID {
+			sendChatMessage(c, systemMessage(room, "textFlagged", drawer.Username))
+			sendEvent(room, c, "textFlagged", map[string]interface{}{
+				"playerId": drawer.ID,
+				"reason":   reason,
+			})
+		}
+	}
+
+	if room.Settings.TextGuard != TextGuardBlock {
+		return
+	}
+
+	log.Printf("✍️ Skipping the turn of %s for writing\n", drawer.Username)
+	broadcastChatMessage(room, systemMessage(room, "textBlocked", drawer.Username))
+	broadcastEvent(room, "roundSkipped", map[string]interface{}{
+		"reason": "writing",
+	})
+
+	// endRound takes the lock itself, so it runs once we're done here
+	state := room.GameState
+	goRoom(room, "endRound", func() {
+		endRound(room, state)
+	})
+}
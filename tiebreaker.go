@@ -0,0 +1,149 @@
+package main
+
+import (
+	"log"
+	"sort"
+)
+
+// Maximum number of sudden-death turns before the tie is accepted
+const maxTiebreakerTurns = 6
+
+// tiedLeaders returns the IDs of the top two players if they share the
+// highest score and nobody else does
+func tiedLeaders(room *Room) []string {
+	// mutex is already locked by caller function
+	clients := make([]*Client, 0, len(room.Clients))
+	for _, c := range room.Clients {
+		if c.Type != "spectator" {
+			clients = append(clients, c)
+		}
+	}
+
+	if len(clients) < 2 {
+		return nil
+	}
+
+	scores := room.Scores.Snapshot()
+	sort.Slice(clients, func(i, j int) bool {
+		return scores[clients[i].ID] > scores[clients[j].ID]
+	})
+
+	if scores[clients[0].ID] != scores[clients[1].ID] {
+		return nil
+	}
+
+	// A three-way tie isn't something two players can settle
+	if len(clients) > 2 && scores[clients[2].ID] == scores[clients[0].ID] {
+		return nil
+	}
+
+	return []string{clients[0].ID, clients[1].ID}
+}
+
+// needsTiebreaker reports whether another sudden-death turn should be
+// played before the final results are sent
+func needsTiebreaker(room *Room) bool {
+	// mutex is already locked by caller function
+	if len(room.TiebreakerPlayers) == 0 {
+		room.TiebreakerPlayers = tiedLeaders(room)
+		room.TiebreakerTurns = 0
+		return room.TiebreakerPlayers != nil
+	}
+
+	// Both contestants must still be around
+	_, okA := room.Clients[room.TiebreakerPlayers[0]]
+	_, okB := room.Clients[room.TiebreakerPlayers[1]]
+	if !okA || !okB {
+		return false
+	}
+
+	// Only compare once both players have had the same number of turns
+	if room.TiebreakerTurns%2 != 0 {
+		return true
+	}
+
+	return room.Scores.Score(room.TiebreakerPlayers[0]) == room.Scores.Score(room.TiebreakerPlayers[1]) &&
+		room.TiebreakerTurns < maxTiebreakerTurns
+}
+
+// startTiebreakerRound starts a turn where one tied player draws and the
+// other guesses, everyone else only watches
+func startTiebreakerRound(room *Room) {
+	// mutex is already locked by caller function
+	drawerID := room.TiebreakerPlayers[room.TiebreakerTurns%2]
+	room.TiebreakerTurns++
+	room.CurrentDrawer = drawerID
+
+	log.Printf("⚔️ Tiebreaker turn %d, drawer: %s\n", room.TiebreakerTurns, drawerID)
+
+	if room.TiebreakerTurns == 1 {
+		broadcastChatMessage(room, systemMessage(room, "tiebreakerStart", room.Clients[room.TiebreakerPlayers[0]].Username, room.Clients[room.TiebreakerPlayers[1]].Username))
+		broadcastEvent(room, "tiebreakerStarted", map[string]interface{}{
+			"playerIds": room.TiebreakerPlayers,
+		})
+	}
+
+	room.GameState = &GameState{
+		IsActive:       true,
+		Phase:          PhaseChoosing,
+		CurrentDrawer:  drawerID,
+		TimeRemaining:  room.Settings.RoundTime,
+		RoundTime:      room.Settings.RoundTime,
+		RoundNumber:    room.Round,
+		TotalRounds:    totalRounds(room),
+		TurnNumber:     room.TiebreakerTurns,
+		WordChoices:    getRandomWords(room, wordChoiceCount(room)),
+		Tiebreaker:     true,
+		HintPolicy:     room.Settings.HintPolicy,
+		CanvasWidth:    room.Settings.CanvasWidth,
+		CanvasHeight:   room.Settings.CanvasHeight,
+		PlayersGuessed: make(map[string]bool),
+	}
+
+	broadcastGameState(room)
+	broadcastPlayers(room)
+	recordTurnStart(room)
+	clearCanvas(room)
+
+	broadcastChatMessage(room, systemMessage(room, "tiebreakerRound"))
+	broadcastEvent(room, "turnStarted", map[string]interface{}{
+		"roundNumber": room.GameState.RoundNumber,
+		"turnNumber":  room.GameState.TurnNumber,
+		"drawerId":    room.GameState.CurrentDrawer,
+		"tiebreaker":  true,
+	})
+}
+
+// canGuess reports whether a client may score in the current round
+func canGuess(room *Room, clientID string) bool {
+	// mutex is already locked by caller function
+	if clientID == room.GameState.CurrentDrawer {
+		return false
+	}
+
+	if c, ok := room.Clients[clientID]; ok && c.Type == "spectator" {
+		return false
+	}
+
+	if room.GameState.Tiebreaker {
+		for _, id := range room.TiebreakerPlayers {
+			if id == clientID {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// hasGuessers reports whether anyone in the room can still guess this round
+func hasGuessers(room *Room) bool {
+	// mutex is already locked by caller function
+	for id := range room.Clients {
+		if canGuess(room, id) {
+			return true
+		}
+	}
+	return false
+}
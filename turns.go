@@ -0,0 +1,176 @@
+package main
+
+import "sort"
+
+const (
+	defaultRounds = 3
+	minRounds     = 1
+	maxRounds     = 10
+
+	upNextCount = 3 // Upcoming drawers shown in the game state
+)
+
+// nextTurn picks the next drawer. A round is over once every player in it
+// has drawn, then the next one starts with everyone still here, in the same
+// order as before. It reports false once the last round is over
+func nextTurn(room *Room) (string, bool) {
+	// mutex is already locked by caller function
+	for {
+		for len(room.DrawQueue) > 0 {
+			drawerID := room.DrawQueue[0]
+			room.DrawQueue = room.DrawQueue[1:]
+
+			// Players who left or became spectators lose their turn
+			if c, ok := room.Clients[drawerID]; ok && c.Type != "spectator" {
+				room.Turn++
+				return drawerID, true
+			}
+		}
+
+		if !hasNextRound(room) || countPlayers(room) == 0 {
+			return "", false
+		}
+
+		room.Round++
+		room.Turn = 0
+		room.Rotation = rotationOrder(room)
+		room.DrawQueue = append([]string(nil), room.Rotation...)
+	}
+}
+
+// rotationOrder is the order players draw in each round: the order of the
+// last round for those still here, then anyone new shuffled in after them.
+// The first round of a game is shuffled from scratch. In classroom mode the
+// fixed drawer has the only turn of every round
+func rotationOrder(room *Room) []string {
+	// mutex is already locked by caller function
+	order, newcomers := nextRotation(room)
+	room.Rand.Shuffle(len(newcomers), func(i, j int) {
+		newcomers[i], newcomers[j] = newcomers[j], newcomers[i]
+	})
+	return append(order, newcomers...)
+}
+
+// nextRotation splits who draws next round into those keeping their place
+// from the last round and the newcomers to shuffle in. Newcomers are sorted,
+// so a room with a pinned seed shuffles them the same way every time
+func nextRotation(room *Room) ([]string, []string) {
+	// mutex is already locked by caller function
+	if room.Settings.Mode == ModeClassroom {
+		if drawerID := classroomDrawer(room); drawerID != "" {
+			return []string{drawerID}, nil
+		}
+	}
+
+	order := []string{}
+	for _, id := range room.Rotation {
+		if c, ok := room.Clients[id]; ok && c.Type != "spectator" {
+			order = append(order, id)
+		}
+	}
+
+	newcomers := []string{}
+	for id, c := range room.Clients {
+		if c.Type != "spectator" && !containsID(order, id) {
+			newcomers = append(newcomers, id)
+		}
+	}
+	sort.Strings(newcomers)
+
+	return order, newcomers
+}
+
+// upcomingDrawers previews who draws next: the rest of this round, then
+// the start of the next one if there is one. Newcomers aren't shuffled in
+// until the next round starts, the preview lists them after everyone else
+// without drawing from the room's random source
+func upcomingDrawers(room *Room) []string {
+	// mutex is already locked by caller function
+	upcoming := []string{}
+	for _, id := range room.DrawQueue {
+		if c, ok := room.Clients[id]; ok && c.Type != "spectator" && len(upcoming) < upNextCount {
+			upcoming = append(upcoming, id)
+		}
+	}
+
+	if hasNextRound(room) {
+		order, newcomers := nextRotation(room)
+		for _, id := range append(order, newcomers...) {
+			if len(upcoming) < upNextCount {
+				upcoming = append(upcoming, id)
+			}
+		}
+	}
+	return upcoming
+}
+
+// hasNextRound reports whether another round follows the current one.
+// Marathons go on for as long as they are played
+func hasNextRound(room *Room) bool {
+	// mutex is already locked by caller function
+	return room.Settings.Mode == ModeMarathon || room.Round < room.Settings.Rounds
+}
+
+// totalRounds is the number of rounds the game has, 0 for a marathon
+func totalRounds(room *Room) int {
+	// mutex is already locked by caller function
+	if room.Settings.Mode == ModeMarathon {
+		return 0
+	}
+	return room.Settings.Rounds
+}
+
+func containsID(ids []string, id string) bool {
+	for _, other := range ids {
+		if other == id {
+			return true
+		}
+	}
+	return false
+}
+
+// turnsInRound counts the turns of the current round, leaving out players
+// who left before their turn came up
+func turnsInRound(room *Room) int {
+	// mutex is already locked by caller function
+	turns := room.Turn
+	for _, id := range room.DrawQueue {
+		if c, ok := room.Clients[id]; ok && c.Type != "spectator" {
+			turns++
+		}
+	}
+	return turns
+}
+
+// joinRotation gives a player who joins mid-game a turn at the end of the
+// current round
+func joinRotation(room *Room, client *Client) {
+	// mutex is already locked by caller function
+	if room.Round == 0 || client.Type == "spectator" || room.Settings.Mode == ModeClassroom || hasPendingTurn(room, client.ID) {
+		return
+	}
+
+	room.DrawQueue = append(room.DrawQueue, client.ID)
+	if !containsID(room.Rotation, client.ID) {
+		room.Rotation = append(room.Rotation, client.ID)
+	}
+	if room.GameState.IsActive && !room.GameState.Tiebreaker {
+		room.GameState.TurnsInRound = turnsInRound(room)
+		room.GameState.UpNext = upcomingDrawers(room)
+	}
+}
+
+// hasPendingTurn reports whether a player still gets to draw this round
+func hasPendingTurn(room *Room, clientID string) bool {
+	// mutex is already locked by caller function
+	return containsID(room.DrawQueue, clientID)
+}
+
+// resetTurns puts the game back before its first round
+func resetTurns(room *Room) {
+	// mutex is already locked by caller function
+	room.Round = 0
+	room.Turn = 0
+	room.DrawQueue = nil
+	room.Rotation = nil
+}
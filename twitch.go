@@ -0,0 +1,157 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	twitchChatURL     = "wss://irc-ws.chat.twitch.tv:443"
+	twitchPlayerID    = "twitch"      // Scoreboard ID of the audience as a whole
+	twitchAnonymous   = "justinfan42" // Twitch lets anyone read chat under a justinfan name
+	twitchRetryDelay  = 10 * time.Second
+	twitchGuessPoints = 100
+)
+
+// twitchChannel is the Twitch channel whose chat can guess along, set with
+// TWITCH_CHANNEL. Rooms turn it on with the twitchGuessing setting
+var twitchChannel = strings.ToLower(strings.TrimPrefix(os.Getenv("TWITCH_CHANNEL"), "#"))
+
+// startTwitchChat reads the channel's chat for as long as the server runs,
+// reconnecting whenever Twitch drops the connection
+func startTwitchChat() {
+	if twitchChannel == "" {
+		return
+	}
+
+	log.Printf("📺 Reading guesses from Twitch channel %s\n", twitchChannel)
+	go func() {
+		for {
+			if err := readTwitchChat(); err != nil {
+				log.Printf("⚠️ Twitch chat disconnected: %v\n", err)
+			}
+			time.Sleep(twitchRetryDelay)
+		}
+	}()
+}
+
+func readTwitchChat() error {
+	conn, _, err := websocket.DefaultDialer.Dial(twitchChatURL, nil)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for _, line := range []string{
+		"CAP REQ :twitch.tv/tags",
+		"NICK " + twitchAnonymous,
+		"JOIN #" + twitchChannel,
+	} {
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(line)); err != nil {
+			return err
+		}
+	}
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\r\n") {
+			if strings.HasPrefix(line, "PING") {
+				conn.WriteMessage(websocket.TextMessage, []byte("PONG"+strings.TrimPrefix(line, "PING")))
+				continue
+			}
+			if name, text, ok := parseTwitchMessage(line); ok {
+				twitchGuess(name, text)
+			}
+		}
+	}
+}
+
+// parseTwitchMessage reads who said what from an IRC PRIVMSG line, using
+// the display name tag when Twitch sends it
+func parseTwitchMessage(line string) (string, string, bool) {
+	tags := ""
+	if strings.HasPrefix(line, "@") {
+		tags, line, _ = strings.Cut(line[1:], " ")
+	}
+
+	prefix, rest, ok := strings.Cut(strings.TrimPrefix(line, ":"), " ")
+	if !ok || !strings.HasPrefix(rest, "PRIVMSG ") {
+		return "", "", false
+	}
+	_, text, ok := strings.Cut(rest, " :")
+	if !ok {
+		return "", "", false
+	}
+
+	name, _, _ := strings.Cut(prefix, "!")
+	for _, tag := range strings.Split(tags, ";") {
+		if value, ok := strings.CutPrefix(tag, "display-name="); ok && value != "" {
+			name = value
+		}
+	}
+	return name, text, true
+}
+
+// twitchGuessing reports whether the room plays against Twitch chat
+func twitchGuessing(room *Room) bool {
+	// mutex is already locked by caller function
+	return twitchChannel != "" && room.Settings.TwitchGuessing
+}
+
+// twitchGuess checks a chatter's message against the word. Chat guesses as
+// one player: the first chatter to get it scores for the whole audience
+// and is named in the room
+func twitchGuess(name string, text string) {
+	room.mu.Lock()
+	defer room.mu.Unlock()
+
+	state := room.GameState
+	if !twitchGuessing(room) || !state.IsActive || state.CurrentWord == "" || state.TwitchGuesser != "" ||
+		!guessMatches(room, text, state.CurrentWord) {
+		return
+	}
+
+	if beginBatch(room) {
+		defer flushBatch(room)
+	}
+
+	state.TwitchGuesser = name
+	room.Scores.Award(twitchPlayerID, twitchGuessPoints, "correctGuess")
+	log.Printf("📺 %s from Twitch chat guessed the word\n", name)
+
+	broadcastChatMessage(room, systemMessage(room, "twitchGuessed", name))
+	broadcastEvent(room, "correctGuess", map[string]interface{}{
+		"playerId": twitchPlayerID,
+		"username": name,
+		"points":   twitchGuessPoints,
+	})
+	recordReplay(room, "correctGuess", map[string]interface{}{
+		"playerId": twitchPlayerID,
+		"points":   twitchGuessPoints,
+	})
+	broadcastPlayers(room)
+}
+
+// twitchPlayer is the audience as it shows up in the players list, if the
+// room plays against it
+func twitchPlayer(room *Room) (Player, bool) {
+	// mutex is already locked by caller function
+	if !twitchGuessing(room) {
+		return Player{}, false
+	}
+
+	return Player{
+		ID:       twitchPlayerID,
+		Username: "Twitch chat",
+		Type:     "audience",
+		Score:    room.Scores.Score(twitchPlayerID),
+	}, true
+}
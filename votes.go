@@ -0,0 +1,121 @@
+package main
+
+import (
+	"time"
+)
+
+type Report struct {
+	ReporterID string    `json:"reporterId"`
+	TargetID   string    `json:"targetId"`
+	TargetName string    `json:"targetName"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// Most reports kept per room
+const maxReports = 100
+
+// votesNeeded is a strict majority of the players, not counting whoever
+// the vote is about
+func votesNeeded(room *Room, excludeID string) int {
+	// mutex is already locked by caller function
+	voters := countPlayers(room)
+	if c, ok := room.Clients[excludeID]; ok && c.Type != "spectator" {
+		voters--
+	}
+	return voters/2 + 1
+}
+
+// voteKick records a vote to kick target and kicks them once a majority
+// agrees
+func voteKick(room *Room, voter *Client, target *Client) {
+	// mutex is already locked by caller function
+	if room.KickVotes[target.ID] == nil {
+		room.KickVotes[target.ID] = make(map[string]bool)
+	}
+	room.KickVotes[target.ID][voter.ID] = true
+
+	// Votes from players who left don't count
+	votes := 0
+	for id := range room.KickVotes[target.ID] {
+		if _, ok := room.Clients[id]; ok {
+			votes++
+		}
+	}
+	needed := votesNeeded(room, target.ID)
+
+	broadcastChatMessage(room, systemMessage(room, "voteKick", voter.Username, target.Username, votes, needed))
+	broadcastEvent(room, "vote", map[string]interface{}{
+		"kind":     "kick",
+		"voterId":  voter.ID,
+		"targetId": target.ID,
+		"votes":    votes,
+		"needed":   needed,
+	})
+
+	if votes >= needed {
+		delete(room.KickVotes, target.ID)
+		kickClient(room, target)
+	}
+}
+
+// voteSkip records a vote to skip the round and skips it once a majority
+// agrees
+func voteSkip(room *Room, voter *Client) {
+	// mutex is already locked by caller function
+	room.SkipVotes[voter.ID] = true
+
+	votes := 0
+	for id := range room.SkipVotes {
+		if _, ok := room.Clients[id]; ok {
+			votes++
+		}
+	}
+	needed := votesNeeded(room, "")
+
+	broadcastChatMessage(room, systemMessage(room, "voteSkip", voter.Username, votes, needed))
+	broadcastEvent(room, "vote", map[string]interface{}{
+		"kind":    "skip",
+		"voterId": voter.ID,
+		"votes":   votes,
+		"needed":  needed,
+	})
+
+	if votes >= needed {
+		room.SkipVotes = make(map[string]bool)
+		broadcastChatMessage(room, systemMessage(room, "votePassed"))
+		broadcastEvent(room, "roundSkipped", map[string]interface{}{
+			"reason": "vote",
+		})
+
+		// endRound takes the lock itself, so it runs once we're done here
+		state := room.GameState
+		goRoom(room, "endRound", func() {
+			endRound(room, state)
+		})
+	}
+}
+
+// reportPlayer files a report for moderators and lets the owner know
+func reportPlayer(room *Room, reporter *Client, target *Client, reason string) {
+	// mutex is already locked by caller function
+	if len(room.Reports) >= maxReports {
+		room.Reports = room.Reports[1:]
+	}
+	room.Reports = append(room.Reports, Report{
+		ReporterID: reporter.ID,
+		TargetID:   target.ID,
+		TargetName: target.Username,
+		Reason:     reason,
+		CreatedAt:  time.Now(),
+	})
+
+	clientLogf(reporter, "🚩 %s reported %s: %s\n", reporter.Username, target.Username, reason)
+	adminFeed.Publish("report", room.Reports[len(room.Reports)-1])
+
+	for _, c := range room.Clients {
+		if c.Type == "owner" {
+			sendChatMessage(c, systemMessage(room, "playerReported", reporter.Username, target.Username, reason))
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxImportSize = 5 * 1024 * 1024
+
+// Difficulties a word can be filed under when it is imported
+var wordDifficulties = []string{"easy", "medium", "hard"}
+
+// WordEntry is one word of a bulk import, with where it is filed
+type WordEntry struct {
+	Word       string `json:"word"`
+	Category   string `json:"category,omitempty"`
+	Difficulty string `json:"difficulty,omitempty"`
+	Language   string `json:"language,omitempty"`
+}
+
+func isValidDifficulty(difficulty string) bool {
+	for _, d := range wordDifficulties {
+		if d == difficulty {
+			return true
+		}
+	}
+	return false
+}
+
+// Import files a batch of words under their languages and categories in one
+// save. Words already in their category only get their difficulty updated
+func (s *WordStore) Import(entries []WordEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, entry := range entries {
+		if s.Packs[entry.Language] == nil {
+			s.Packs[entry.Language] = make(map[string][]string)
+		}
+		if words := s.Packs[entry.Language][entry.Category]; !containsWord(words, entry.Word) {
+			s.Packs[entry.Language][entry.Category] = append(words, entry.Word)
+		}
+
+		if entry.Difficulty == "" {
+			continue
+		}
+		if s.Difficulty == nil {
+			s.Difficulty = make(map[string]map[string]string)
+		}
+		if s.Difficulty[entry.Language] == nil {
+			s.Difficulty[entry.Language] = make(map[string]string)
+		}
+		s.Difficulty[entry.Language][strings.ToLower(entry.Word)] = entry.Difficulty
+	}
+
+	return s.save()
+}
+
+// Difficulties returns a copy of the difficulty words of a language were
+// imported with
+func (s *WordStore) Difficulties(language string) map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	difficulties := make(map[string]string)
+	for word, difficulty := range s.Difficulty[language] {
+		difficulties[word] = difficulty
+	}
+	return difficulties
+}
+
+// parseWordCSV reads an import in CSV. The header row names the columns:
+// "word" is required, "category", "difficulty" and "language" are optional
+// and may come in any order
+func parseWordCSV(body io.Reader) ([]WordEntry, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, errors.New("missing header row")
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["word"]; !ok {
+		return nil, errors.New(`the header has no "word" column`)
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	entries := []WordEntry{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, WordEntry{
+			Word:       field(record, "word"),
+			Category:   field(record, "category"),
+			Difficulty: field(record, "difficulty"),
+			Language:   field(record, "language"),
+		})
+	}
+	return entries, nil
+}
+
+// normalizeEntries fills in defaults for what an entry leaves out and checks
+// the rest, naming the first bad row. Rows count from 1, after any header
+func normalizeEntries(entries []WordEntry, language string, category string) error {
+	for i := range entries {
+		entry := &entries[i]
+		entry.Word = strings.TrimSpace(entry.Word)
+		entry.Category = strings.ToLower(strings.TrimSpace(entry.Category))
+		entry.Difficulty = strings.ToLower(strings.TrimSpace(entry.Difficulty))
+		entry.Language = strings.ToLower(strings.TrimSpace(entry.Language))
+
+		if entry.Category == "" {
+			entry.Category = category
+		}
+		if entry.Language == "" {
+			entry.Language = language
+		}
+
+		switch {
+		case entry.Word == "":
+			return fmt.Errorf("row %d: missing word", i+1)
+		case entry.Difficulty != "" && !isValidDifficulty(entry.Difficulty):
+			return fmt.Errorf("row %d: difficulty must be one of %s", i+1, strings.Join(wordDifficulties, ", "))
+		}
+	}
+	return nil
+}
+
+// importWordsHandler adds words in bulk from a CSV or JSON body, picked by
+// its Content-Type. A JSON body is an array of entries. The "language" and
+// "category" query parameters are the defaults for entries without one.
+// Nothing is imported if any row is bad
+func importWordsHandler(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+	var entries []WordEntry
+	var err error
+	if c.ContentType() == "text/csv" {
+		entries, err = parseWordCSV(c.Request.Body)
+	} else {
+		err = json.NewDecoder(c.Request.Body).Decode(&entries)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "invalid import: " + err.Error(),
+		})
+		return
+	}
+
+	if len(entries) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "no words given",
+		})
+		return
+	}
+
+	language := c.DefaultQuery("language", defaultLanguage)
+	category := c.DefaultQuery("category", defaultCategory)
+	if err := normalizeEntries(entries, language, category); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	if err := wordStore.Import(entries); err != nil {
+		logError(c.GetString("requestId"), "Failed to save words", err)
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to save words",
+		})
+		return
+	}
+
+	log.Printf("📝 Imported %d words\n", len(entries))
+	c.JSON(http.StatusOK, gin.H{
+		"imported": len(entries),
+	})
+}
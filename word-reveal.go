@@ -0,0 +1,58 @@
+package main
+
+// Guesser is a player who found the word, and how long it took them
+type Guesser struct {
+	PlayerID    string `json:"playerId"`
+	Username    string `json:"username"`
+	TimeToGuess int64  `json:"timeToGuess"` // Milliseconds
+}
+
+// WordReveal is shown during the intermission after a turn
+type WordReveal struct {
+	Word       string      `json:"word"`
+	BonusWord  string      `json:"bonusWord,omitempty"`
+	DrawerID   string      `json:"drawerId"`
+	GuessedBy  []Guesser   `json:"guessedBy"` // In the order they guessed
+	Definition *Definition `json:"definition,omitempty"`
+	DrawingURL string      `json:"drawingUrl,omitempty"` // SVG of the final drawing, add .png for an image
+
+	TwitchGuesser string `json:"twitchGuesser,omitempty"` // Chatter who got it for Twitch chat
+}
+
+// broadcastWordReveal sends everyone the turn's word along with who
+// guessed it, the link to the drawing and, if the dictionary knows it, what
+// it means
+func broadcastWordReveal(room *Room, bonusWord string, drawingURL string) {
+	// mutex is already locked by caller function
+	reveal := WordReveal{
+		Word:       room.GameState.CurrentWord,
+		BonusWord:  bonusWord,
+		DrawerID:   room.GameState.CurrentDrawer,
+		GuessedBy:  []Guesser{},
+		DrawingURL: drawingURL,
+
+		TwitchGuesser: room.GameState.TwitchGuesser,
+	}
+
+	for i, id := range room.GameState.GuessOrder {
+		guesser := Guesser{
+			PlayerID:    id,
+			TimeToGuess: room.GameState.GuessTimes[i],
+		}
+		if c, ok := room.Clients[id]; ok {
+			guesser.Username = c.Username
+		}
+		reveal.GuessedBy = append(reveal.GuessedBy, guesser)
+	}
+
+	if dictionary != nil {
+		if definition, ok := dictionary.Define(room.Settings.Language, reveal.Word); ok {
+			reveal.Definition = &definition
+		}
+	}
+
+	broadcastMessage(room, Message{
+		Type: "wordReveal",
+		Data: reveal,
+	})
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	minStatsRounds = 5 // Rounds a word is drawn in before it gets a difficulty
+
+	// Share of guessers finding a word, and the average time they take,
+	// that mark it as easy or hard
+	easyGuessRate = 0.7
+	hardGuessRate = 0.3
+	easyGuessTime = 30000 // Milliseconds
+
+	// How often changed stats and suggestions are saved, rounds end and
+	// players suggest too often to rewrite the whole word store for each
+	wordStoreFlushInterval = 30 * time.Second
+)
+
+// WordStats is how a word has fared over every round it was drawn in
+type WordStats struct {
+	Rounds    int   `json:"rounds"`
+	Guessers  int   `json:"guessers"`  // Players who could have guessed it
+	Guessed   int   `json:"guessed"`   // Players who did
+	GuessTime int64 `json:"guessTime"` // Total milliseconds the correct guesses took
+}
+
+// WordStatsReport is a word's stats with the figures derived from them
+type WordStatsReport struct {
+	Word string `json:"word"`
+	WordStats
+	GuessRate        float64 `json:"guessRate"`
+	AverageGuessTime int64   `json:"averageGuessTime"` // Milliseconds
+	Difficulty       string  `json:"difficulty,omitempty"`
+}
+
+func (w WordStats) GuessRate() float64 {
+	if w.Guessers == 0 {
+		return 0
+	}
+	return float64(w.Guessed) / float64(w.Guessers)
+}
+
+func (w WordStats) AverageGuessTime() int64 {
+	if w.Guessed == 0 {
+		return 0
+	}
+	return w.GuessTime / int64(w.Guessed)
+}
+
+// Difficulty tags a word easy, medium or hard from how often and how fast
+// it is guessed, once it has been drawn often enough to tell
+func (w WordStats) Difficulty() string {
+	if w.Rounds < minStatsRounds {
+		return ""
+	}
+
+	switch rate := w.GuessRate(); {
+	case rate < hardGuessRate:
+		return "hard"
+	case rate >= easyGuessRate && w.AverageGuessTime() < easyGuessTime:
+		return "easy"
+	default:
+		return "medium"
+	}
+}
+
+// RecordRound adds a round's outcome to the word's stats, guessTimes holds
+// how long each correct guess took. They are saved by flushLoop
+func (s *WordStore) RecordRound(language string, word string, guessers int, guessTimes []int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Stats == nil {
+		s.Stats = make(map[string]map[string]*WordStats)
+	}
+	if s.Stats[language] == nil {
+		s.Stats[language] = make(map[string]*WordStats)
+	}
+
+	word = strings.ToLower(word)
+	stats, ok := s.Stats[language][word]
+	if !ok {
+		stats = &WordStats{}
+		s.Stats[language][word] = stats
+	}
+
+	// Guessers who left before the round ended still count
+	stats.Rounds++
+	stats.Guessers += max(guessers, len(guessTimes))
+	stats.Guessed += len(guessTimes)
+	for _, guessTime := range guessTimes {
+		stats.GuessTime += guessTime
+	}
+	s.dirty = true
+}
+
+// flushLoop saves recorded stats and suggestions every
+// wordStoreFlushInterval
+func (s *WordStore) flushLoop() {
+	ticker := time.NewTicker(wordStoreFlushInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.Flush()
+	}
+}
+
+// Flush saves the stats and suggestions recorded since the last save,
+// unless something else saved the store in the meantime
+func (s *WordStore) Flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.dirty {
+		return
+	}
+	if err := s.save(); err != nil {
+		logError("words", "Failed to save word store", err)
+	}
+}
+
+// WordStats reports on every word of a language that has been drawn,
+// hardest first. Words not drawn often enough to tell keep the difficulty
+// they were imported with
+func (s *WordStore) WordStats(language string) []WordStatsReport {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	reports := []WordStatsReport{}
+	for word, stats := range s.Stats[language] {
+		difficulty := stats.Difficulty()
+		if difficulty == "" {
+			difficulty = s.Difficulty[language][word]
+		}
+
+		reports = append(reports, WordStatsReport{
+			Word:             word,
+			WordStats:        *stats,
+			GuessRate:        stats.GuessRate(),
+			AverageGuessTime: stats.AverageGuessTime(),
+			Difficulty:       difficulty,
+		})
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		if reports[i].GuessRate != reports[j].GuessRate {
+			return reports[i].GuessRate < reports[j].GuessRate
+		}
+		return reports[i].AverageGuessTime > reports[j].AverageGuessTime
+	})
+	return reports
+}
+
+// recordWordStats saves how the round's word fared
+func recordWordStats(room *Room, guessers int) {
+	// mutex is already locked by caller function
+	word := room.GameState.CurrentWord
+	if word == "" {
+		return
+	}
+
+	wordStore.RecordRound(room.Settings.Language, word, guessers, room.GameState.GuessTimes)
+}
+
+// wordStatsHandler returns the word stats of one language, or of all of
+// them when no "language" query parameter is given
+func wordStatsHandler(c *gin.Context) {
+	languages := wordStore.Languages()
+	if language := c.Query("language"); language != "" {
+		languages = []string{language}
+	}
+
+	stats := make(map[string][]WordStatsReport)
+	for _, language := range languages {
+		stats[language] = wordStore.WordStats(language)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"stats": stats,
+	})
+}
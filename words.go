@@ -1,22 +1,128 @@
 package main
 
-import "math/rand"
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
 
-func getRandomWords(count int) []string {
-	shuffled := make([]string, len(Words))
-	copy(shuffled, Words)
+// wordPack returns the words for a language, English if it has none
+func wordPack(language string) []string {
+	if words := wordStore.Words(language); len(words) > 0 {
+		return words
+	}
+	return wordStore.Words(defaultLanguage)
+}
+
+// roomWords returns the words of the room's language that fit its word
+// categories and length limits, or the whole pack if too few of them do.
+// A category the players voted for stands in for the room's categories
+func roomWords(room *Room, count int) []string {
+	// mutex is already locked by caller function
+	words := wordPack(room.Settings.Language)
+
+	categories := []string{}
+	if room.NextCategory != "" {
+		categories = []string{room.NextCategory}
+	} else if room.Settings.WordCategories != "" {
+		categories = strings.Split(room.Settings.WordCategories, ",")
+	}
+	if len(categories) > 0 {
+		if picked := wordStore.CategoryWords(room.Settings.Language, categories); len(picked) >= count {
+			words = picked
+		}
+	}
+
+	fitting := []string{}
+	for _, word := range words {
+		length := len([]rune(word))
+		if (room.Settings.MinWordLength == 0 || length >= room.Settings.MinWordLength) &&
+			(room.Settings.MaxWordLength == 0 || length <= room.Settings.MaxWordLength) {
+			fitting = append(fitting, word)
+		}
+	}
+
+	if len(fitting) < count {
+		return words
+	}
+	return fitting
+}
+
+// getRandomWords picks words for the drawer to choose from. Recently played
+// words are only offered once the rest of the pack runs out
+func getRandomWords(room *Room, count int) []string {
+	// mutex is already locked by caller function
+	language := room.Settings.Language
+	words := roomWords(room, count)
+	shuffled := make([]string, len(words))
+	copy(shuffled, words)
 
-	rand.Shuffle(len(shuffled), func(i, j int) {
+	room.Rand.Shuffle(len(shuffled), func(i, j int) {
 		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
 	})
 
-	return shuffled[:count]
+	recent := recentWords.Set(language)
+	sort.SliceStable(shuffled, func(i, j int) bool {
+		return !recent[strings.ToLower(shuffled[i])] && recent[strings.ToLower(shuffled[j])]
+	})
+
+	return shuffled[:min(count, len(shuffled))]
 }
 
-func generateHint(word string) string {
+const (
+	maxWordCategories   = 10
+	maxCategoryNameSize = 30
+)
+
+// parseCategories cleans up a comma separated wordCategories setting,
+// reporting whether it is usable. Empty means every category
+func parseCategories(value string) (string, bool) {
+	categories := []string{}
+	for _, category := range strings.Split(value, ",") {
+		category = strings.TrimSpace(category)
+		if category == "" || containsWord(categories, category) {
+			continue
+		}
+		if len(category) > maxCategoryNameSize || strings.ContainsAny(category, "\n\r") {
+			return "", false
+		}
+		categories = append(categories, category)
+	}
+
+	if len(categories) > maxWordCategories {
+		return "", false
+	}
+	return strings.Join(categories, ","), true
+}
+
+const (
+	HintNone       = "none"       // Guessers get no hint at all
+	HintFirstLast  = "firstLast"  // First and last letter shown
+	HintTimed      = "timed"      // Letters are revealed as the round goes on
+	HintLengthOnly = "lengthOnly" // Only the length of the word is shown
+)
+
+func isValidHintPolicy(policy string) bool {
+	switch policy {
+	case HintNone, HintFirstLast, HintTimed, HintLengthOnly:
+		return true
+	}
+	return false
+}
+
+func generateHint(word string, policy string) string {
+	switch policy {
+	case HintNone:
+		return ""
+	case HintTimed, HintLengthOnly:
+		return maskWord(word)
+	}
+
+	letters := []rune(word)
 	hint := ""
-	for i, char := range word {
-		if i == 0 || i == len(word)-1 {
+	for i, char := range letters {
+		if i == 0 || i == len(letters)-1 {
 			hint += string(char)
 		} else {
 			hint += "_"
@@ -24,3 +130,107 @@ func generateHint(word string) string {
 	}
 	return hint
 }
+
+// maskWord hides every letter of the word but keeps the spaces
+func maskWord(word string) string {
+	hint := ""
+	for _, char := range word {
+		if char == ' ' {
+			hint += " "
+		} else {
+			hint += "_"
+		}
+	}
+	return hint
+}
+
+// countHidden returns how many letters of the hint are still hidden
+func countHidden(hint string) int {
+	return strings.Count(hint, "_")
+}
+
+func getBonusWord(room *Room, currentWord string) string {
+	// mutex is already locked by caller function
+	language := room.Settings.Language
+	words := roomWords(room, 2)
+	recent := recentWords.Set(language)
+	for attempt := range 100 {
+		word := words[room.Rand.Intn(len(words))]
+
+		// Give up on avoiding recent words if the pack is mostly recent
+		if word != currentWord && (!recent[strings.ToLower(word)] || attempt >= 50) {
+			return word
+		}
+	}
+
+	// A pack this small has no other word to offer
+	return ""
+}
+
+// revealLetter uncovers one random hidden letter of the hint
+func revealLetter(room *Room, hint string, word string) string {
+	// mutex is already locked by caller function
+	hintRunes := []rune(hint)
+	wordRunes := []rune(word)
+
+	hidden := []int{}
+	for i, char := range hintRunes {
+		if char == '_' && i < len(wordRunes) {
+			hidden = append(hidden, i)
+		}
+	}
+
+	if len(hidden) == 0 {
+		return hint
+	}
+
+	i := hidden[room.Rand.Intn(len(hidden))]
+	hintRunes[i] = wordRunes[i]
+	return string(hintRunes)
+}
+
+// guessMatches reports whether a guess is the word under the room's
+// language rules. With containsMatch on, a message that has the word in it
+// as a whole word, like "is it apple?", counts too
+func guessMatches(room *Room, guess string, word string) bool {
+	// mutex is already locked by caller function
+	if word == "" {
+		return false
+	}
+
+	language := room.Settings.Language
+	guess = normalizeGuess(language, guess)
+	word = normalizeGuess(language, word)
+
+	if guess == word {
+		return true
+	}
+	return room.Settings.ContainsMatch && containsWholeWord(guess, word)
+}
+
+// containsWholeWord reports whether word appears in text with no letters or
+// digits directly around it
+func containsWholeWord(text string, word string) bool {
+	for offset := 0; offset < len(text); {
+		i := strings.Index(text[offset:], word)
+		if i < 0 {
+			return false
+		}
+		start := offset + i
+		end := start + len(word)
+
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if (start == 0 || !isWordRune(before)) && (end == len(text) || !isWordRune(after)) {
+			return true
+		}
+
+		_, size := utf8.DecodeRuneInString(text[start:])
+		offset = start + size
+	}
+	return false
+}
+
+func isWordRune(char rune) bool {
+	return unicode.IsLetter(char) || unicode.IsDigit(char)
+}
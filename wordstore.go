@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	defaultCategory  = "general"
+	defaultWordsFile = "words.json"
+	minPackWords     = 5 // Enough for a full set of word choices
+)
+
+var (
+	errUnknownCategory = errors.New("unknown category")
+	errPackTooSmall    = errors.New("a language needs at least 5 words")
+)
+
+// WordStore holds the vocabulary by language and category, along with how
+// each word fared in play, and saves every change to a JSON file so it
+// survives restarts
+type WordStore struct {
+	mu      sync.RWMutex
+	path    string
+	Packs   map[string]map[string][]string `json:"packs"`   // Language to category to words
+	Pending []Suggestion                   `json:"pending"` // Player suggestions awaiting review
+
+	Stats      map[string]map[string]*WordStats `json:"stats,omitempty"`      // Language to word to how it fared
+	Difficulty map[string]map[string]string     `json:"difficulty,omitempty"` // Language to word to the difficulty it was imported with
+
+	dirty bool // Stats or suggestions changed since the last save, see flushLoop
+}
+
+var wordStore = loadWordStore(wordsFile())
+
+func wordsFile() string {
+	if path := os.Getenv("WORDS_FILE"); path != "" {
+		return path
+	}
+	return defaultWordsFile
+}
+
+// loadWordStore reads the saved vocabulary, falling back to the built-in
+// word packs when there is none yet
+func loadWordStore(path string) *WordStore {
+	store := &WordStore{
+		path:  path,
+		Packs: make(map[string]map[string][]string),
+	}
+
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if err := json.Unmarshal(data, store); err == nil && len(store.Packs) > 0 {
+			go store.flushLoop()
+			return store
+		}
+		log.Printf("⚠️ Ignoring unreadable word store %s\n", path)
+	}
+
+	store.Packs = make(map[string]map[string][]string)
+	for language, words := range WordPacks {
+		store.Packs[language] = map[string][]string{
+			defaultCategory: append([]string(nil), words...),
+		}
+	}
+	go store.flushLoop()
+	return store
+}
+
+// Words returns every word of a language across all its categories
+func (s *WordStore) Words(language string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	words := []string{}
+	for _, category := range s.Packs[language] {
+		words = append(words, category...)
+	}
+	return words
+}
+
+func (s *WordStore) HasLanguage(language string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.Packs[language]
+	return ok
+}
+
+func (s *WordStore) Languages() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	languages := []string{}
+	for language := range s.Packs {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Categories returns a copy of a language's categories
+func (s *WordStore) Categories(language string) map[string][]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	categories := make(map[string][]string)
+	for name, words := range s.Packs[language] {
+		categories[name] = append([]string(nil), words...)
+	}
+	return categories
+}
+
+// CategoryWords returns the words of a language in any of the given
+// categories, ignoring categories it doesn't have
+func (s *WordStore) CategoryWords(language string, categories []string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	words := []string{}
+	for _, category := range categories {
+		words = append(words, s.Packs[language][category]...)
+	}
+	return words
+}
+
+// Add puts words into a category, creating the language and category if
+// needed. Words already in the category are skipped
+func (s *WordStore) Add(language string, category string, words []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Packs[language] == nil {
+		s.Packs[language] = make(map[string][]string)
+	}
+
+	existing := s.Packs[language][category]
+	for _, word := range words {
+		word = strings.TrimSpace(word)
+		if word != "" && !containsWord(existing, word) {
+			existing = append(existing, word)
+		}
+	}
+	s.Packs[language][category] = existing
+
+	return s.save()
+}
+
+// Remove takes words out of a category, or the whole category when no
+// words are given. A language can't shrink below a playable size
+func (s *WordStore) Remove(language string, category string, words []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.Packs[language][category]
+	if !ok {
+		return errUnknownCategory
+	}
+
+	kept := []string{}
+	if len(words) > 0 {
+		for _, word := range existing {
+			if !containsWord(words, word) {
+				kept = append(kept, word)
+			}
+		}
+	}
+
+	total := len(kept)
+	for name, other := range s.Packs[language] {
+		if name != category {
+			total += len(other)
+		}
+	}
+	if total < minPackWords {
+		return errPackTooSmall
+	}
+
+	if len(kept) == 0 {
+		delete(s.Packs[language], category)
+	} else {
+		s.Packs[language][category] = kept
+	}
+
+	return s.save()
+}
+
+// save writes the store to a temporary file first so a crash never leaves
+// a half-written vocabulary behind
+func (s *WordStore) save() error {
+	// mutex is already locked by caller function
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+	s.dirty = false
+	return nil
+}
+
+// CategoryOf returns the category a word of a language is filed under
+func (s *WordStore) CategoryOf(language string, word string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for category, words := range s.Packs[language] {
+		if containsWord(words, word) {
+			return category
+		}
+	}
+	return ""
+}
+
+func containsWord(words []string, word string) bool {
+	for _, w := range words {
+		if strings.EqualFold(w, word) {
+			return true
+		}
+	}
+	return false
+}